@@ -35,3 +35,8 @@ func (s *ErrorFormatter) Write(writer io.Writer) error {
 	_, err := writer.Write(append(s.content, '\n'))
 	return err
 }
+
+// Table implements formatter.IDataSourceFormatter.
+func (s *ErrorFormatter) Table() *formatter.Table {
+	return nil
+}
@@ -0,0 +1,142 @@
+package datasourceparser
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
+
+// extendsDirective is the base data source a csv data source extends with '# extends: <file>', and how its rows change the base:
+// rows with the value of the '# key: <column>' of a base row replace it, other rows are added and '# delete: <key>, ...' removes base rows
+type extendsDirective struct {
+	base       string   // The reference of the base data source, resolved against the directory of the data source
+	line       int      // 1-based line of the 'extends' directive
+	key        string   // The key column, empty when rows are only added
+	keyLine    int      // 1-based line of the 'key' directive
+	deletes    []string // The key values of the base rows to delete
+	deleteLine int      // 1-based line of the first 'delete' directive
+}
+
+// derivedSource is a parsed data source that extends a base data source and is merged with it once all data sources are parsed
+type derivedSource struct {
+	directive *extendsDirective
+	filePath  string
+	content   []byte
+}
+
+// parseExtends returns the 'extends', 'key' and 'delete' directives of csv content, or nil when the content does not extend a data source.
+// Without an 'extends' directive the 'key' and 'delete' comments are ordinary comments
+func parseExtends(content []byte, config *formatter.Config, dir string) (*extendsDirective, error) {
+	if config.Filetype != formatter.ParserInputTypeCsv || config.CSV.Comment == "" {
+		return nil, nil
+	}
+	comment := string([]rune(config.CSV.Comment)[0])
+	directiveRegex := regexp.MustCompile(`(?m)^[ \t]*` + regexp.QuoteMeta(comment) + `[ \t]*(extends|key|delete):[ \t]*(.*?)[ \t]*\r?$`)
+	matches := directiveRegex.FindAllSubmatchIndex(content, -1)
+
+	directive := &extendsDirective{}
+	for _, match := range matches {
+		line := bytes.Count(content[:match[0]], []byte("\n")) + 1
+		value := string(content[match[4]:match[5]])
+		switch string(content[match[2]:match[3]]) {
+		case "extends":
+			if directive.line > 0 {
+				return nil, &formatter.ParseError{Line: line, Value: value, Err: fmt.Errorf("'extends' is declared in line %d and again in line %d", directive.line, line)}
+			}
+			if value == "" || strings.HasPrefix(value, "#") || strings.HasSuffix(value, "#") {
+				return nil, &formatter.ParseError{Line: line, Value: value, Err: fmt.Errorf("'extends' in line %d must name a data source file, e.g. '%s extends: ../base/customers.csv'", line, comment)}
+			}
+			directive.base, directive.line = value, line
+			if !filepath.IsAbs(directive.base) {
+				directive.base = filepath.Join(dir, directive.base)
+			}
+		case "key":
+			if directive.keyLine > 0 {
+				return nil, &formatter.ParseError{Line: line, Value: value, Err: fmt.Errorf("'key' is declared in line %d and again in line %d", directive.keyLine, line)}
+			}
+			directive.key, directive.keyLine = value, line
+		case "delete":
+			if directive.deleteLine == 0 {
+				directive.deleteLine = line
+			}
+			for _, key := range strings.Split(value, ",") {
+				if key = strings.TrimSpace(key); key != "" {
+					directive.deletes = append(directive.deletes, key)
+				}
+			}
+		}
+	}
+	if directive.line == 0 {
+		return nil, nil
+	}
+	if directive.key == "" && len(directive.deletes) > 0 {
+		return nil, &formatter.ParseError{Line: directive.deleteLine, Err: fmt.Errorf("'delete' in line %d requires a key column, declare it with '%s key: <column>'", directive.deleteLine, comment)}
+	}
+	return directive, nil
+}
+
+// extendTable returns the rows of the base table changed by the rows and deletes of the derived table.
+// The derived table must have the columns of the base table, and rows are matched on the normalized value of the key column
+func extendTable(base *formatter.Table, derived *formatter.Table, directive *extendsDirective) (*formatter.Table, error) {
+	if len(base.Columns) != len(derived.Columns) {
+		return nil, &formatter.ParseError{Line: directive.line, Err: fmt.Errorf("data source has %d column(s), but '%s' it extends has %d", len(derived.Columns), directive.base, len(base.Columns))}
+	}
+	for i := range base.Columns {
+		if !strings.EqualFold(base.Columns[i].Name, derived.Columns[i].Name) || base.Columns[i].Type != derived.Columns[i].Type {
+			return nil, &formatter.ParseError{
+				Line: directive.line,
+				Err: fmt.Errorf("column %d of data source is '%s %s', but '%s' it extends defines '%s %s'",
+					i+1, derived.Columns[i].Name, derived.Columns[i].Type, directive.base, base.Columns[i].Name, base.Columns[i].Type),
+			}
+		}
+	}
+
+	rows := append([]formatter.Row{}, base.Rows...)
+	if directive.key == "" {
+		return &formatter.Table{Columns: base.Columns, Rows: append(rows, derived.Rows...)}, nil
+	}
+
+	keyIndex := -1
+	for i, column := range base.Columns {
+		if strings.EqualFold(column.Name, directive.key) {
+			keyIndex = i
+		}
+	}
+	if keyIndex < 0 {
+		return nil, &formatter.ParseError{Line: directive.keyLine, Value: directive.key, Err: fmt.Errorf("key column '%s' in line %d is not a column of '%s'", directive.key, directive.keyLine, directive.base)}
+	}
+	keyOf := func(row formatter.Row) string {
+		if keyIndex < len(row.Cells) {
+			return row.Cells[keyIndex].Value
+		}
+		return ""
+	}
+	find := func(key string) int {
+		for i, row := range rows {
+			if keyOf(row) == key {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, key := range directive.deletes {
+		i := find(key)
+		if i < 0 {
+			return nil, &formatter.ParseError{Line: directive.deleteLine, Value: key, Err: fmt.Errorf("cannot delete '%s' in line %d, '%s' has no row with %s '%s'", key, directive.deleteLine, directive.base, directive.key, key)}
+		}
+		rows = append(rows[:i], rows[i+1:]...)
+	}
+	for _, row := range derived.Rows {
+		if i := find(keyOf(row)); i >= 0 {
+			rows[i] = row
+		} else {
+			rows = append(rows, row)
+		}
+	}
+	return &formatter.Table{Columns: base.Columns, Rows: rows}, nil
+}
@@ -0,0 +1,73 @@
+package datasourceparser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
+
+// fixtureSection is a named fixture of a data source with several fixtures, delimited by '# fixture: <name>' comment markers
+type fixtureSection struct {
+	name    string
+	line    int    // 1-based line of the marker
+	content []byte // The content of the section, preceded by an empty line for every line before it so that line numbers match the file
+}
+
+var fixtureNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// splitFragment splits a data source reference such as 'fixtures/orders.csv#late_shipments' into the file path and the fixture name
+func splitFragment(reference string) (string, string) {
+	path, fragment, _ := strings.Cut(reference, "#")
+	return path, fragment
+}
+
+// fixtureSections splits csv content into the sections started by '<comment> fixture: <name>' lines, each with its own header.
+// Content without markers has no sections. Only comments and empty lines may precede the first marker
+func fixtureSections(content []byte, config *formatter.Config) ([]fixtureSection, error) {
+	if config.Filetype != formatter.ParserInputTypeCsv || config.CSV.Comment == "" {
+		return nil, nil
+	}
+	comment := string([]rune(config.CSV.Comment)[0])
+	markerRegex := regexp.MustCompile(`(?m)^[ \t]*` + regexp.QuoteMeta(comment) + `[ \t]*fixture:[ \t]*(.*?)[ \t]*\r?$`)
+	if !markerRegex.Match(content) {
+		return nil, nil
+	}
+
+	var sections []fixtureSection
+	for i, line := range bytes.SplitAfter(content, []byte("\n")) {
+		match := markerRegex.FindSubmatch(bytes.TrimRight(line, "\n"))
+		if match == nil {
+			if len(sections) > 0 {
+				sections[len(sections)-1].content = append(sections[len(sections)-1].content, line...)
+			} else if trimmed := strings.TrimSpace(string(line)); trimmed != "" && !strings.HasPrefix(trimmed, comment) {
+				return nil, &formatter.ParseError{Line: i + 1, Err: fmt.Errorf("line %d is outside of a fixture, expected '%s fixture: <name>' before it", i+1, comment)}
+			}
+			continue
+		}
+
+		name := string(match[1])
+		if !fixtureNameRegex.MatchString(name) {
+			return nil, &formatter.ParseError{Line: i + 1, Value: name, Err: fmt.Errorf("invalid fixture name '%s' in line %d, expected letters, digits, '_', '.' or '-'", name, i+1)}
+		}
+		for _, section := range sections {
+			if section.name == name {
+				return nil, &formatter.ParseError{Line: i + 1, Value: name, Err: fmt.Errorf("fixture '%s' is defined in line %d and again in line %d", name, section.line, i+1)}
+			}
+		}
+		// Keep the lines before the section empty, so that parse errors refer to the lines of the file
+		sections = append(sections, fixtureSection{name: name, line: i + 1, content: bytes.Repeat([]byte("\n"), i+1)})
+	}
+	return sections, nil
+}
+
+// fixtureNames returns the names of the sections
+func fixtureNames(sections []fixtureSection) []string {
+	names := make([]string, len(sections))
+	for i, section := range sections {
+		names[i] = section.name
+	}
+	return names
+}
@@ -1,17 +1,21 @@
 package datasourceparser
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
 	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+	"github.com/tsanton/dbt-unit-test-fusionizer/utilities"
 	"gopkg.in/yaml.v3"
 )
 
@@ -19,8 +23,11 @@ type Parser[T formatter.IDataSourceFormatter] struct {
 	logger             *slog.Logger
 	workers            int
 	dataSourceFiles    map[string]DataSourceFile
+	fixtures           map[string][]string      // The fixture names of the files with '# fixture: <name>' sections
+	derived            map[string]derivedSource // The data sources with an '# extends: <file>' directive, merged with their base once all data sources are parsed
 	defaultConfig      *formatter.Config
 	formatterGenerator func(*slog.Logger, *formatter.Config) T
+	issues             report.Collector
 	mu                 sync.Mutex
 }
 
@@ -28,6 +35,11 @@ func (s *Parser[T]) GetDataSources() *map[string]DataSourceFile {
 	return &s.dataSourceFiles
 }
 
+// Issues returns the errors raised while reading and parsing the data sources
+func (s *Parser[T]) Issues() []report.Issue {
+	return s.issues.Issues()
+}
+
 func NewDatasourceParser[T formatter.IDataSourceFormatter](logger *slog.Logger, workers int, config *formatter.Config, constructor func(*slog.Logger, *formatter.Config) T) *Parser[T] {
 	return &Parser[T]{
 		logger:             logger,
@@ -35,22 +47,58 @@ func NewDatasourceParser[T formatter.IDataSourceFormatter](logger *slog.Logger,
 		defaultConfig:      config,
 		formatterGenerator: constructor,
 		dataSourceFiles:    make(map[string]DataSourceFile),
+		fixtures:           make(map[string][]string),
+		derived:            make(map[string]derivedSource),
 		mu:                 sync.Mutex{},
 	}
 }
 
 type DataSourceFile struct {
-	filePath    string
-	lastChanged time.Time
-	Formatter   formatter.IDataSourceFormatter
+	filePath     string
+	hash         string
+	configHash   string
+	dependencies []string
+	err          error
+	Formatter    formatter.IDataSourceFormatter
+}
+
+// Err returns the error raised while reading or parsing the data source, or nil if it was parsed successfully
+func (d *DataSourceFile) Err() error {
+	return d.err
 }
 
-func (d *DataSourceFile) LastChanged() time.Time {
-	return d.lastChanged
+// FilePath returns the path of the data source
+func (d *DataSourceFile) FilePath() string {
+	return d.filePath
+}
+
+// Hash returns the SHA-256 digest of the data source content. Empty if the data source could not be read
+func (d *DataSourceFile) Hash() string {
+	return d.hash
+}
+
+// ConfigHash returns the SHA-256 digest of the effective config the data source was parsed with
+func (d *DataSourceFile) ConfigHash() string {
+	return d.configHash
+}
+
+// Dependencies returns the references of the data sources the data source extends, directly or through its base
+func (d *DataSourceFile) Dependencies() []string {
+	return d.dependencies
 }
 
 type dataSourceJob struct {
 	dataSourceFilePath string
+	vars               string // The encoded vars of the mock call, parsed with their own copy of the data source
+	inlineContent      []byte // The csv of an inline data source, referenced as '<test template>:<line>'
+}
+
+// key returns the reference the data source, or one of its fixtures, is recorded under
+func (j dataSourceJob) key(fixture string) string {
+	if fixture == "" {
+		return withVars(j.dataSourceFilePath, j.vars)
+	}
+	return withVars(j.dataSourceFilePath+"#"+fixture, j.vars)
 }
 
 func (s *Parser[T]) Parse(c <-chan templatecrawler.DataSourceReference) {
@@ -61,44 +109,163 @@ func (s *Parser[T]) Parse(c <-chan templatecrawler.DataSourceReference) {
 	for i := 0; i < s.workers; i++ {
 		go func() {
 			for j := range jobs {
-				_, ok := s.dataSourceFiles[j.dataSourceFilePath]
-				if !ok {
-					err := s.processDataSource(j)
-					if err != nil {
-						s.logger.Debug(fmt.Sprintf("error processing data source '%s': %s", j.dataSourceFilePath, err.Error())) //Debug here is ok, because it's already logged in processDataSource
-					}
-				} else {
-					s.logger.Debug(fmt.Sprintf("data source '%s' already processed", j.dataSourceFilePath))
+				err := s.processDataSource(j)
+				if err != nil {
+					s.logger.Debug(fmt.Sprintf("error processing data source '%s': %s", j.dataSourceFilePath, err.Error())) //Debug here is ok, because it's already logged in processDataSource
 				}
 				wg.Done()
 			}
 		}()
 	}
 
-	// Push work onto the job queue
+	// Push work onto the job queue. A file is parsed once per combination of vars, also when several of its fixtures are referenced
+	queued := map[string]bool{}
+	var references []string
 	for ref := range c {
-		lowerPath := strings.ToLower(ref.DataSourceFilePath)
-		wg.Add(1)
-		jobs <- dataSourceJob{dataSourceFilePath: lowerPath}
+		if ref.InlineContent != nil {
+			inlinePath, vars := splitVars(ref.DataSourceFilePaths[0])
+			wg.Add(1)
+			jobs <- dataSourceJob{dataSourceFilePath: inlinePath, vars: vars, inlineContent: ref.InlineContent}
+			continue
+		}
+		for _, reference := range ref.DataSourceFilePaths {
+			references = append(references, reference)
+			withoutVars, vars := splitVars(reference)
+			filePath, _ := splitFragment(withoutVars)
+			job := dataSourceJob{dataSourceFilePath: filePath, vars: vars}
+			if queued[job.key("")] {
+				s.logger.Debug(fmt.Sprintf("data source '%s' already processed", job.key("")))
+				continue
+			}
+			queued[job.key("")] = true
+			wg.Add(1)
+			jobs <- job
+		}
 	}
 	wg.Wait()
 	close(jobs) // Close jobs channel after all jobs have been processed
+
+	for _, reference := range references {
+		s.resolveReference(reference)
+	}
+
+	derived := make([]string, 0, len(s.derived))
+	for reference := range s.derived {
+		derived = append(derived, reference)
+	}
+	sort.Strings(derived)
+	for _, reference := range derived {
+		s.resolveDerived(reference, map[string]bool{})
+	}
+}
+
+// resolveDerived merges a data source with an '# extends: <file>' directive with its base, resolving the base first.
+// The base is parsed when no mock references it. Visiting holds the data sources being resolved, to report cycles
+func (s *Parser[T]) resolveDerived(reference string, visiting map[string]bool) {
+	derived, ok := s.derived[reference]
+	if !ok {
+		return
+	}
+	defer delete(s.derived, reference)
+	visiting[reference] = true
+	defer delete(visiting, reference)
+	directive := derived.directive
+	_, vars := splitVars(reference)
+	baseReference := withVars(directive.base, vars)
+
+	if _, ok := s.dataSourceFiles[baseReference]; !ok {
+		withoutVars, _ := splitVars(baseReference)
+		basePath, _ := splitFragment(withoutVars)
+		if _, ok := s.dataSourceFiles[withVars(basePath, vars)]; !ok {
+			_ = s.processDataSource(dataSourceJob{dataSourceFilePath: basePath, vars: vars})
+		}
+		s.resolveReference(baseReference)
+	}
+	cycle := visiting[baseReference]
+	if !cycle {
+		s.resolveDerived(baseReference, visiting)
+	}
+
+	file := s.dataSourceFiles[reference]
+	base := s.dataSourceFiles[baseReference]
+	var err error
+	var table *formatter.Table
+	switch {
+	case cycle:
+		err = &formatter.ParseError{Line: directive.line, Err: fmt.Errorf("'%s' extends '%s', which extends it again", reference, directive.base)}
+	case base.err != nil:
+		err = &formatter.ParseError{Line: directive.line, Err: fmt.Errorf("'%s' extends '%s', which failed to parse: %w", reference, directive.base, base.err)}
+	case base.Formatter.Table() == nil:
+		err = &formatter.ParseError{Line: directive.line, Err: fmt.Errorf("'%s' extends '%s', but only csv data sources can be extended", reference, directive.base)}
+	default:
+		table, err = extendTable(base.Formatter.Table(), file.Formatter.Table(), directive)
+	}
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to extend data source '%s'. %s", reference, err.Error()))
+		s.recordError(reference, file.filePath, derived.content, file.hash, file.configHash, err)
+		return
+	}
+
+	file.dependencies = append([]string{baseReference}, base.dependencies...)
+	file.Formatter = NewTableFormatter(s.logger, table)
+	s.dataSourceFiles[reference] = file
+}
+
+// resolveReference records an error for a referenced data source the files did not provide:
+// a fixture that is not defined, or a whole file that is split into fixtures
+func (s *Parser[T]) resolveReference(reference string) {
+	if _, ok := s.dataSourceFiles[reference]; ok {
+		return
+	}
+	withoutVars, vars := splitVars(reference)
+	filePath, fragment := splitFragment(withoutVars)
+	file := s.dataSourceFiles[withVars(filePath, vars)]
+	names := s.fixtures[withVars(filePath, vars)]
+
+	var err error
+	switch {
+	case file.err != nil:
+		err = file.err
+	case fragment == "":
+		err = fmt.Errorf("data source '%s' is split into the fixtures '%s', reference one of them with '#<name>'", filePath, strings.Join(names, "', '"))
+	case names == nil:
+		err = fmt.Errorf("fixture '%s' not found, data source '%s' has no '# fixture: <name>' sections", fragment, filePath)
+	default:
+		err = fmt.Errorf("fixture '%s' not found in data source '%s', expected one of '%s'", fragment, filePath, strings.Join(names, "', '"))
+	}
+	if file.err == nil {
+		s.logger.Error(err.Error())
+		s.issues.Add(report.Issue{Stage: report.StageParse, File: filePath, Message: err.Error()})
+	}
+	s.dataSourceFiles[reference] = DataSourceFile{
+		filePath:   filePath,
+		hash:       file.hash,
+		configHash: file.configHash,
+		err:        err,
+		Formatter:  NewErrorFormatter(s.logger, err),
+	}
 }
 
 func (s *Parser[T]) processDataSource(job dataSourceJob) error {
 	s.logger.Debug(fmt.Sprintf("processing data source '%s'", job.dataSourceFilePath))
-	fileInfo, err := os.Stat(job.dataSourceFilePath)
+	if job.inlineContent != nil {
+		return s.processInlineDataSource(job)
+	}
+	content, err := os.ReadFile(job.dataSourceFilePath)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("file '%s' not found", job.dataSourceFilePath))
+		err = fmt.Errorf("error reading file '%s': file not found", job.dataSourceFilePath)
 		s.mu.Lock()
-		s.dataSourceFiles[job.dataSourceFilePath] = DataSourceFile{
-			filePath:    job.dataSourceFilePath,
-			lastChanged: time.Date(9999, 12, 31, 23, 59, 0, 0, time.UTC),
-			Formatter:   NewErrorFormatter(s.logger, fmt.Errorf("error reading file '%s': file not found", job.dataSourceFilePath)),
+		s.dataSourceFiles[job.key("")] = DataSourceFile{
+			filePath:  job.dataSourceFilePath,
+			err:       err,
+			Formatter: NewErrorFormatter(s.logger, err),
 		}
 		s.mu.Unlock()
+		s.issues.Add(report.Issue{Stage: report.StageParse, File: job.dataSourceFilePath, Message: err.Error()})
 		return err
 	}
+	hash := utilities.Sha256(content)
 
 	config := &formatter.Config{}
 	yamlFile, err := os.ReadFile(path.Join(filepath.Dir(job.dataSourceFilePath), ".datasourcerer.yaml"))
@@ -107,45 +274,197 @@ func (s *Parser[T]) processDataSource(job dataSourceJob) error {
 		err = yaml.Unmarshal(yamlFile, config)
 		if err != nil {
 			s.logger.Error(fmt.Sprintf("error reading config override '%s': %s", path.Join(filepath.Dir(job.dataSourceFilePath), ".datasourcerer.yaml"), err.Error()))
+			s.issues.Add(report.Issue{Stage: report.StageParse, File: path.Join(filepath.Dir(job.dataSourceFilePath), ".datasourcerer.yaml"), Message: err.Error()})
 		}
+		config.Types = mergeTypes(s.defaultConfig.Types, config.Types)
 		if config.Filetype == "csv" && !config.CSV.Validate() {
 			s.logger.Error(fmt.Sprintf("csv config is not valid in directory '%s'. Using default CSV config", filepath.Dir(job.dataSourceFilePath)))
+			s.issues.Add(report.Issue{Stage: report.StageParse, File: path.Join(filepath.Dir(job.dataSourceFilePath), ".datasourcerer.yaml"), Message: "csv config is not valid, 'separator' and 'comment' are required"})
 			config = &formatter.Config{
 				Filetype: "csv",
 				CSV:      formatter.NewDefaultCsvConfig(),
+				Types:    config.Types,
 			}
 		}
 	} else {
 		s.logger.Debug(fmt.Sprintf("using default config to parse file '%s'", job.dataSourceFilePath))
-		config = s.defaultConfig
+		defaultConfig := *s.defaultConfig // copy, the default config is shared between workers
+		config = &defaultConfig
 		if config.Filetype == "csv" && !config.CSV.Validate() {
 			s.logger.Debug(fmt.Sprintf("using default csv config to parse file '%s'", job.dataSourceFilePath))
 			config.CSV = formatter.NewDefaultCsvConfig()
 		}
 	}
 
-	file, _ := os.Open(job.dataSourceFilePath)
-	f := s.formatterGenerator(s.logger, config)
-	err = f.Read(file)
+	configHash, err := hashConfig(config)
+	if err != nil {
+		s.issues.Add(report.Issue{Stage: report.StageParse, File: job.dataSourceFilePath, Message: err.Error()})
+		return err
+	}
+
+	vars, err := resolveVars(config, job.vars)
+	if err == nil {
+		var substituted []byte
+		if substituted, err = substituteVars(content, vars); err == nil {
+			content = substituted
+		}
+	}
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to substitute the vars of data source '%s'. %s", job.key(""), err.Error()))
+		s.recordError(job.key(""), job.dataSourceFilePath, content, hash, configHash, err)
+		return err
+	}
+
+	sections, err := fixtureSections(content, config)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("failed to parse data source '%s'. %s", job.dataSourceFilePath, err.Error()))
+		s.logger.Error(fmt.Sprintf("failed to split data source '%s' into fixtures. %s", job.dataSourceFilePath, err.Error()))
+		s.recordError(job.key(""), job.dataSourceFilePath, content, hash, configHash, err)
+		return err
+	}
+	if sections == nil {
+		return s.parseContent(job.key(""), job.dataSourceFilePath, content, config, configHash)
+	}
+
+	s.mu.Lock()
+	s.fixtures[job.key("")] = fixtureNames(sections)
+	s.mu.Unlock()
+	var firstErr error
+	for _, section := range sections {
+		if err := s.parseContent(job.key(section.name), job.dataSourceFilePath, section.content, config, configHash); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// processInlineDataSource parses the csv written in a mock call of a test template with the default csv config
+func (s *Parser[T]) processInlineDataSource(job dataSourceJob) error {
+	templatePath := job.dataSourceFilePath[:strings.LastIndex(job.dataSourceFilePath, ":")]
+	config := *s.defaultConfig // copy, the default config is shared between workers
+	config.Filetype = formatter.ParserInputTypeCsv
+	if !config.CSV.Validate() {
+		config.CSV = formatter.NewDefaultCsvConfig()
+	}
+	configHash, err := hashConfig(&config)
+	if err != nil {
+		s.issues.Add(report.Issue{Stage: report.StageParse, File: templatePath, Message: err.Error()})
+		return err
+	}
+	vars, err := resolveVars(&config, job.vars)
+	var content []byte
+	if err == nil {
+		content, err = substituteVars(job.inlineContent, vars)
+	}
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to substitute the vars of data source '%s'. %s", job.key(""), err.Error()))
+		s.recordError(job.key(""), templatePath, job.inlineContent, utilities.Sha256(job.inlineContent), configHash, err)
+		return err
+	}
+	return s.parseContent(job.key(""), templatePath, content, &config, configHash)
+}
+
+// recordError records a data source that could not be parsed under the reference, and the issue located in the file path
+func (s *Parser[T]) recordError(reference string, filePath string, content []byte, hash string, configHash string, err error) {
+	s.mu.Lock()
+	s.dataSourceFiles[reference] = DataSourceFile{
+		filePath:   filePath,
+		hash:       hash,
+		configHash: configHash,
+		err:        err,
+		Formatter:  NewErrorFormatter(s.logger, err),
+	}
+	s.mu.Unlock()
+	s.issues.Add(parseIssue(filePath, content, err))
+}
+
+// parseContent parses the content of a data source, one of its fixtures or an inline data source, and records the result under the reference.
+// The file path is the file the content is read from, the data source file or the test template
+func (s *Parser[T]) parseContent(reference string, filePath string, content []byte, config *formatter.Config, configHash string) error {
+	hash := utilities.Sha256(content)
+	// The uuids of a data source are seeded with its file name and fixture, not its directory, so they do not change with the checkout location
+	withoutVars, vars := splitVars(reference)
+	sourceConfig := *config
+	sourceConfig.CSV.Expressions.Source = filepath.Base(withoutVars)
+	f := s.formatterGenerator(s.logger, &sourceConfig)
+	err := f.Read(bytes.NewReader(content))
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to parse data source '%s'. %s", reference, err.Error()))
 		s.mu.Lock()
-		s.dataSourceFiles[job.dataSourceFilePath] = DataSourceFile{
-			filePath:    job.dataSourceFilePath,
-			lastChanged: fileInfo.ModTime(),
-			Formatter:   NewErrorFormatter(s.logger, err),
+		s.dataSourceFiles[reference] = DataSourceFile{
+			filePath:   filePath,
+			hash:       hash,
+			configHash: configHash,
+			err:        err,
+			Formatter:  NewErrorFormatter(s.logger, err),
 		}
 		s.mu.Unlock()
+		s.issues.Add(parseIssue(filePath, content, err))
+		return err
+	}
+
+	directive, err := parseExtends(content, config, filepath.Dir(withoutVars))
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to parse the 'extends' directive of data source '%s'. %s", reference, err.Error()))
+		s.recordError(reference, filePath, content, hash, configHash, err)
 		return err
 	}
 
-	//read and parse the content here, and add it as a []byte to the DataSourceFile{}? Shouldn't due to lazy loading and lastChanged
 	s.mu.Lock()
-	s.dataSourceFiles[job.dataSourceFilePath] = DataSourceFile{
-		filePath:    job.dataSourceFilePath,
-		lastChanged: fileInfo.ModTime(),
-		Formatter:   f,
+	s.dataSourceFiles[reference] = DataSourceFile{
+		filePath:   filePath,
+		hash:       hash,
+		configHash: configHash,
+		Formatter:  f,
+	}
+	if directive != nil {
+		s.logger.Debug(fmt.Sprintf("data source '%s' extends '%s'", reference, withVars(directive.base, vars)))
+		s.derived[reference] = derivedSource{directive: directive, filePath: filePath, content: content}
 	}
 	s.mu.Unlock()
 	return nil
 }
+
+// hashConfig returns the SHA-256 digest of the config, used to detect when the effective config of a data source changes
+// mergeTypes returns the types of the root config with the types of a config override added. A type of the override redefines the root type of the same name
+func mergeTypes(root formatter.Types, override formatter.Types) formatter.Types {
+	if len(root) == 0 {
+		return override
+	}
+	types := formatter.Types{}
+	for name, config := range root {
+		types[name] = config
+	}
+	for name, config := range override {
+		types[name] = config
+	}
+	return types
+}
+
+func hashConfig(config *formatter.Config) (string, error) {
+	content, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("error serializing config: %w", err)
+	}
+	return utilities.Sha256(content), nil
+}
+
+// parseIssue returns the issue for a data source that failed to parse, located at the offending line and value when known
+func parseIssue(filePath string, content []byte, err error) report.Issue {
+	issue := report.Issue{Stage: report.StageParse, File: filePath, Message: err.Error()}
+	parseErr := formatter.AsParseError(err)
+	if parseErr == nil {
+		return issue
+	}
+
+	issue.Line = parseErr.Line
+	issue.Column = parseErr.Column
+	issue.ColumnIndex = parseErr.ColumnIndex
+	issue.ColumnName = parseErr.ColumnName
+	issue.Value = parseErr.Value
+	width := report.FieldWidth(content, parseErr.Line, parseErr.Column, parseErr.Value)
+	if parseErr.Column > 0 {
+		issue.EndColumn = parseErr.Column + width
+	}
+	issue.Snippet = report.Snippet(content, parseErr.Line, parseErr.Column, width)
+	return issue
+}
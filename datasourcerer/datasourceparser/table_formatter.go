@@ -0,0 +1,39 @@
+package datasourceparser
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
+
+var _ formatter.IDataSourceFormatter = &TableFormatter{}
+
+// TableFormatter writes a table that was resolved from other data sources, e.g. a data source extending a base data source
+type TableFormatter struct {
+	logger *slog.Logger
+	table  *formatter.Table
+}
+
+func NewTableFormatter(logger *slog.Logger, table *formatter.Table) *TableFormatter {
+	return &TableFormatter{
+		logger: logger,
+		table:  table,
+	}
+}
+
+// Read implements formatter.IDataSourceFormatter.
+func (s *TableFormatter) Read(r io.Reader) error {
+	return nil
+}
+
+// Write implements formatter.IDataSourceFormatter.
+func (s *TableFormatter) Write(writer io.Writer) error {
+	_, err := writer.Write(append(s.table.Sql(), '\n'))
+	return err
+}
+
+// Table implements formatter.IDataSourceFormatter.
+func (s *TableFormatter) Table() *formatter.Table {
+	return s.table
+}
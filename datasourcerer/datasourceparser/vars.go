@@ -0,0 +1,86 @@
+package datasourceparser
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
+
+// varRegex matches the '$${' escape and the '${name}' and '{{ name }}' placeholders of a data source
+var varRegex = regexp.MustCompile(`\$\$\{|\$\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}|\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// splitVars splits a data source reference such as 'fixtures/orders.csv#late?customer_id=42' into the reference without vars and the encoded vars of the mock call
+func splitVars(reference string) (string, string) {
+	withoutVars, query, _ := strings.Cut(reference, "?")
+	return withoutVars, query
+}
+
+// withVars appends the encoded vars of a mock call to a data source reference
+func withVars(reference string, query string) string {
+	if query == "" {
+		return reference
+	}
+	return reference + "?" + query
+}
+
+// resolveVars returns the vars of the config overridden by the encoded vars of the mock call
+func resolveVars(config *formatter.Config, query string) (map[string]string, error) {
+	vars := map[string]string{}
+	for name, value := range config.Vars {
+		vars[name] = value
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vars '%s': %w", query, err)
+	}
+	for name := range values {
+		vars[name] = values.Get(name)
+	}
+	return vars, nil
+}
+
+// substituteVars replaces the '${name}' and '{{ name }}' placeholders of the content with the vars, before the content is parsed and its values validated.
+// An undefined '${name}' is an error, while an undefined '{{ name }}' is kept for dbt to render. '$${' writes a literal '${'
+func substituteVars(content []byte, vars map[string]string) ([]byte, error) {
+	if !varRegex.Match(content) {
+		return content, nil
+	}
+
+	var out bytes.Buffer
+	pos := 0
+	for _, match := range varRegex.FindAllSubmatchIndex(content, -1) {
+		out.Write(content[pos:match[0]])
+		pos = match[1]
+		placeholder := content[match[0]:match[1]]
+		switch {
+		case match[2] >= 0:
+			name := string(content[match[2]:match[3]])
+			value, ok := vars[name]
+			if !ok {
+				line := bytes.Count(content[:match[0]], []byte("\n")) + 1
+				column := match[0] - (bytes.LastIndexByte(content[:match[0]], '\n') + 1) + 1
+				return nil, &formatter.ParseError{
+					Line:   line,
+					Column: column,
+					Value:  string(placeholder),
+					Err:    fmt.Errorf("var '%s' in line %d is not defined, pass it in the 'vars' of the mock call or define it in 'vars' of the config", name, line),
+				}
+			}
+			out.WriteString(value)
+		case match[4] >= 0:
+			if value, ok := vars[string(content[match[4]:match[5]])]; ok {
+				out.WriteString(value)
+			} else {
+				out.Write(placeholder)
+			}
+		default:
+			out.WriteString("${")
+		}
+	}
+	out.Write(content[pos:])
+	return out.Bytes(), nil
+}
@@ -0,0 +1,141 @@
+package unit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func runDiagnostics(t *testing.T, dataContent string) (*report.Report, string) {
+	ds, out := testutils.BootstrapDirs()
+	t.Cleanup(func() { testutils.CleanupDir(ds, out) })
+
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "datasource.csv", dataContent, map[string]interface{}{})
+	assert.Nil(t, err)
+	_, err = testutils.CreateFile(ds.D1, "test_diagnostics.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	return rep, dataSourceFile.Name()
+}
+
+func Test_Snowflake_Csv_Diagnostics_Value(t *testing.T) {
+	rep, file := runDiagnostics(t, strings.Join([]string{
+		`"Id[number(10,0)]",Name,"Income[number(20,2)]"`,
+		`# the comment line counts`,
+		`1,John,100.1`,
+		`2,Jane,<MALFORMED>`,
+	}, "\n"))
+
+	assert.Len(t, rep.Issues(), 1)
+	issue := rep.Issues()[0]
+	assert.Equal(t, report.Issue{
+		Stage:       report.StageParse,
+		File:        file,
+		Message:     "error parsing value '<MALFORMED>' for column 'INCOME' in line 4",
+		Line:        4,
+		Column:      8,
+		EndColumn:   19,
+		ColumnIndex: 3,
+		ColumnName:  "INCOME",
+		Value:       "<MALFORMED>",
+		Snippet:     "4 | 2,Jane,<MALFORMED>\n  |        ^^^^^^^^^^^",
+	}, issue)
+
+	var summary bytes.Buffer
+	assert.Nil(t, rep.WriteSummary(&summary))
+	assert.True(t, strings.HasPrefix(summary.String(), "[parse] "+file+":4:8: error parsing value '<MALFORMED>' for column 'INCOME' in line 4 (column 3)\n4 | 2,Jane,<MALFORMED>\n  |        ^^^^^^^^^^^\n"))
+}
+
+func Test_Snowflake_Csv_Diagnostics_Header(t *testing.T) {
+	rep, file := runDiagnostics(t, strings.Join([]string{
+		`Id,"Name[unknown(1)]"`,
+		`1,John`,
+	}, "\n"))
+
+	assert.Len(t, rep.Issues(), 1)
+	issue := rep.Issues()[0]
+	assert.Equal(t, file, issue.File)
+	assert.Equal(t, "unable to parse header `Name[unknown(1)]`", issue.Message)
+	assert.Equal(t, 1, issue.Line)
+	assert.Equal(t, 4, issue.Column)
+	assert.Equal(t, 2, issue.ColumnIndex)
+	assert.Equal(t, "1 | Id,\"Name[unknown(1)]\"\n  |    ^^^^^^^^^^^^^^^^^^", issue.Snippet)
+}
+
+func Test_Snowflake_Csv_Diagnostics_TooManyValues(t *testing.T) {
+	rep, _ := runDiagnostics(t, strings.Join([]string{
+		`Id,Name`,
+		`1,John,extra`,
+	}, "\n"))
+
+	assert.Len(t, rep.Issues(), 1)
+	issue := rep.Issues()[0]
+	assert.Equal(t, "line 2 has 3 values, but the header defines 2 columns", issue.Message)
+	assert.Equal(t, 2, issue.Line)
+	assert.Equal(t, 8, issue.Column)
+	assert.Equal(t, 3, issue.ColumnIndex)
+}
+
+func Test_Snowflake_Csv_Diagnostics_Sarif(t *testing.T) {
+	rep, file := runDiagnostics(t, strings.Join([]string{
+		`"Id[number(10,0)]",Name`,
+		`<MALFORMED>,John`,
+	}, "\n"))
+
+	var out bytes.Buffer
+	assert.Nil(t, rep.WriteSarif(&out, "1.0.0"))
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string                `json:"ruleId"`
+				Message   struct{ Text string } `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string } `json:"artifactLocation"`
+						Region           struct {
+							StartLine   int `json:"startLine"`
+							StartColumn int `json:"startColumn"`
+							EndColumn   int `json:"endColumn"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				Properties map[string]interface{} `json:"properties"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &sarif))
+	assert.Equal(t, "2.1.0", sarif.Version)
+	assert.Len(t, sarif.Runs, 1)
+	assert.Equal(t, "datasourcerer", sarif.Runs[0].Tool.Driver.Name)
+	assert.Equal(t, "1.0.0", sarif.Runs[0].Tool.Driver.Version)
+	assert.Len(t, sarif.Runs[0].Results, 1)
+	result := sarif.Runs[0].Results[0]
+	assert.Equal(t, "datasourcerer/parse", result.RuleID)
+	assert.Equal(t, "error parsing value '<MALFORMED>' for column 'ID' in line 2", result.Message.Text)
+	location := result.Locations[0].PhysicalLocation
+	assert.Equal(t, file, location.ArtifactLocation.URI)
+	assert.Equal(t, 2, location.Region.StartLine)
+	assert.Equal(t, 1, location.Region.StartColumn)
+	assert.Equal(t, 12, location.Region.EndColumn)
+	assert.Equal(t, "ID", result.Properties["columnName"])
+	assert.Equal(t, float64(1), result.Properties["columnIndex"])
+}
@@ -0,0 +1,116 @@
+package unit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/generator"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+const errorModeTestContent = `
+{{ config(tags=['unit-test']) }}
+
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+
+	{% call dbt_unit_testing.mock_ref ('<source-name>', {'source_file': '${0}' }) %}
+	{% endcall %}
+
+	{% call dbt_unit_testing.expect() %}
+select 'Gunnar' as name
+	{% endcall %}
+
+{% endcall %}
+`
+
+func Test_Snowflake_Csv_ErrorMode_Raise(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataContent := strings.TrimSpace(`
+"Id[number(10,0)]",Name,"Income[number(20,2)]"
+1,John,100.1
+2,Jane,"<MALFORMED ""100"">"
+	`)
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "datasource.csv", dataContent, map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.TrimSpace(errorModeTestContent)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	err = testutils.RunWithErrorMode(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir, generator.ErrorModeRaise)
+	assert.Nil(t, err)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content:    `{{ exceptions.raise_compiler_error("datasourcerer: datasource.csv:3: error parsing value '<MALFORMED \"100\">' for column 'INCOME' in line 3") }}`,
+	}
+	expected := testutils.Merge(t, testContent, format.Values{"0": dataSourceFile.Name()}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_ErrorMode_Raise_DoesNotExist(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	testContent := strings.TrimSpace(errorModeTestContent)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": "../fixtures/does_not_exist.csv"})
+	assert.Nil(t, err)
+
+	err = testutils.RunWithErrorMode(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir, generator.ErrorModeRaise)
+	assert.Nil(t, err)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content:    `{{ exceptions.raise_compiler_error("datasourcerer: ../fixtures/does_not_exist.csv: error reading file '${1}': file not found") }}`,
+	}
+	expected := testutils.Merge(t, testContent, format.Values{
+		"0": "../fixtures/does_not_exist.csv",
+		"1": filepath.Join(filepath.Dir(filepath.Dir(testFile.Name())), "fixtures/does_not_exist.csv"),
+	}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_ErrorMode_Strict(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	validFile, err := testutils.CreateFile(ds.D1, "valid.csv", "\"Id[number(10,0)]\",Name\n1,John", map[string]interface{}{})
+	assert.Nil(t, err)
+	malformedFile, err := testutils.CreateFile(ds.D1, "malformed.csv", "\"Income[number(20,2)]\"\n<MALFORMED>", map[string]interface{}{})
+	assert.Nil(t, err)
+	validTest, err := testutils.CreateFile(ds.D1, "test_valid.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": validFile.Name()})
+	assert.Nil(t, err)
+	malformedTest, err := testutils.CreateFile(ds.D1, "test_malformed.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": malformedFile.Name()})
+	assert.Nil(t, err)
+
+	err = testutils.RunWithErrorMode(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir, generator.ErrorModeStrict)
+	assert.EqualError(t, err, "failed to generate 1 test file(s)")
+
+	_, err = testutils.GetGeneratorFile(out.RootDir, ds.RootDir, validTest.Name())
+	assert.Nil(t, err)
+	rel, err := filepath.Rel(ds.RootDir, malformedTest.Name())
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(out.RootDir, rel))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,92 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+const expressionsTestData = `
+"Id[number(10,0)]","Shipped[date(MM/dd/yyyy)]","CreatedAt[timestamp_ntz()]",Note
+=seq(1000),=today-3d,=now,=today
+=seq(1000),=today,=now+2h,==today
+`
+
+func expressionsConfig() *formatter.Config {
+	config := &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}
+	config.CSV.Expressions = formatter.ExpressionsConfig{ReferenceDate: "2024-01-31T08:30:00Z", Seed: 42}
+	return config
+}
+
+func Test_Snowflake_Csv_Expressions(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "orders.csv", strings.TrimSpace(expressionsTestData), map[string]interface{}{})
+	assert.Nil(t, err)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	testutils.Run(logger, expressionsConfig(), ds.RootDir, out.RootDir)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content: strings.TrimSpace(`
+SELECT 1000::NUMBER(10,0) AS ID, '2024-01-28'::DATE AS SHIPPED, '2024-01-31 08:30:00'::TIMESTAMP_NTZ(9) AS CREATEDAT, '2024-01-31'::VARCHAR(16777216) AS NOTE
+UNION ALL
+SELECT 1001::NUMBER(10,0) AS ID, '2024-01-31'::DATE AS SHIPPED, '2024-01-31 10:30:00'::TIMESTAMP_NTZ(9) AS CREATEDAT, '=today'::VARCHAR(16777216) AS NOTE
+`),
+	}
+	expected := testutils.Merge(t, strings.TrimSpace(errorModeTestContent), format.Values{"0": dataSourceFile.Name()}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_Expressions_Uuid(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "orders.csv", "Id\n=uuid()\n=uuid()", map[string]interface{}{})
+	assert.Nil(t, err)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	/* The expressions of text columns are evaluated, and the seed makes the uuids reproducible between generations */
+	config := expressionsConfig()
+	testutils.Run(logger, config, ds.RootDir, out.RootDir)
+	first, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	testutils.Run(logger, config, ds.RootDir, out.RootDir)
+	second, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+	assert.Regexp(t, `SELECT '[0-9a-f-]{36}'::VARCHAR\(16777216\) AS ID\nUNION ALL\nSELECT '[0-9a-f-]{36}'::VARCHAR\(16777216\) AS ID\n`, first)
+}
+
+func Test_Snowflake_Csv_Expressions_Errors(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataContent := strings.Replace(strings.TrimSpace(expressionsTestData), "=today-3d", "=today-3h", 1)
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "orders.csv", dataContent, map[string]interface{}{})
+	assert.Nil(t, err)
+	_, err = testutils.CreateFile(ds.D1, "test_snowflake.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, expressionsConfig(), ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	issues := rep.Issues()
+	assert.Len(t, issues, 1)
+	assert.Equal(t, "error evaluating expression '=today-3h' for column 'SHIPPED' in line 2: 'today' takes offsets in d, w, mo or y, use 'now' for 'h'", issues[0].Message)
+	assert.Equal(t, 2, issues[0].Line)
+	assert.Equal(t, 12, issues[0].Column)
+}
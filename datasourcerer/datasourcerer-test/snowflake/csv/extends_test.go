@@ -0,0 +1,155 @@
+package unit_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/generator"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+const extendsBaseTestData = `
+"Id[number(10,0)]",Name,"Birthday[date()]"
+1,John,1990-12-24
+2,Jane,2000-02-01
+3,Kari,1970-01-01
+`
+
+const extendsTestData = `
+# extends: ../customers.csv
+# key: id
+# delete: 3
+"Id[number(10,0)]",Name,"Birthday[date()]"
+2,Janet,2000-02-01
+4,Ola,1985-05-05
+`
+
+func Test_Snowflake_Csv_Extends(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+	config := &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}
+	lockPath := filepath.Join(ds.RootDir, ".datasourcerer.lock")
+
+	baseFile, err := testutils.CreateFile(ds.D1, "customers.csv", strings.TrimSpace(extendsBaseTestData), map[string]interface{}{})
+	assert.Nil(t, err)
+	dataSourceFile, err := testutils.CreateFile(ds.D2, "customers_renamed.csv", strings.TrimSpace(extendsTestData), map[string]interface{}{})
+	assert.Nil(t, err)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	assert.Nil(t, testutils.RunLocked(logger, config, ds.RootDir, out.RootDir, lockPath, "1.0.0"))
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content: strings.TrimSpace(`
+SELECT 1::NUMBER(10,0) AS ID, 'John'::VARCHAR(16777216) AS NAME, '1990-12-24'::DATE AS BIRTHDAY
+UNION ALL
+SELECT 2::NUMBER(10,0) AS ID, 'Janet'::VARCHAR(16777216) AS NAME, '2000-02-01'::DATE AS BIRTHDAY
+UNION ALL
+SELECT 4::NUMBER(10,0) AS ID, 'Ola'::VARCHAR(16777216) AS NAME, '1985-05-05'::DATE AS BIRTHDAY
+`),
+	}
+	expected := testutils.Merge(t, strings.TrimSpace(errorModeTestContent), format.Values{"0": dataSourceFile.Name()}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+
+	/* The base is recorded in the lock, and a changed base regenerates the derived test */
+	content, err := os.ReadFile(lockPath)
+	assert.Nil(t, err)
+	lock := &generator.LockFile{}
+	assert.Nil(t, json.Unmarshal(content, lock))
+	key := filepath.ToSlash(strings.TrimPrefix(testFile.Name(), ds.RootDir+string(os.PathSeparator)))
+	baseKey := filepath.ToSlash(strings.TrimPrefix(baseFile.Name(), ds.RootDir+string(os.PathSeparator)))
+	assert.Contains(t, lock.Tests[key].Fixtures, baseKey)
+
+	assert.Nil(t, os.WriteFile(baseFile.Name(), []byte(strings.Replace(strings.TrimSpace(extendsBaseTestData), "John", "Johan", 1)), 0644))
+	assert.Nil(t, testutils.RunLocked(logger, config, ds.RootDir, out.RootDir, lockPath, "1.0.0"))
+	result, err = testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Replace(expected, "'John'", "'Johan'", 1), result)
+}
+
+func Test_Snowflake_Csv_Extends_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		base    string
+		message string
+		line    int
+	}{
+		{
+			name:    "unknown key",
+			content: strings.Replace(extendsTestData, "# key: id", "# key: customer_id", 1),
+			message: "key column 'customer_id' in line 2 is not a column of '${base}'",
+			line:    2,
+		},
+		{
+			name:    "delete without key",
+			content: strings.Replace(extendsTestData, "# key: id\n", "", 1),
+			message: "'delete' in line 2 requires a key column, declare it with '# key: <column>'",
+			line:    2,
+		},
+		{
+			name:    "delete missing row",
+			content: strings.Replace(extendsTestData, "# delete: 3", "# delete: 3, 9", 1),
+			message: "cannot delete '9' in line 3, '${base}' has no row with id '9'",
+			line:    3,
+		},
+		{
+			name:    "columns",
+			content: strings.Replace(extendsTestData, `"Birthday[date()]"`, `"Birthday[varchar(10)]"`, 1),
+			message: "column 3 of data source is 'BIRTHDAY VARCHAR(10)', but '${base}' it extends defines 'BIRTHDAY DATE'",
+			line:    1,
+		},
+		{
+			name:    "cycle",
+			content: extendsTestData,
+			base:    "# extends: d2/customers_renamed.csv\n" + strings.TrimSpace(extendsBaseTestData),
+			message: "'${derived}' extends '${base}', which failed to parse: '${base}' extends '${derived}', which extends it again",
+			line:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, out := testutils.BootstrapDirs()
+			defer testutils.CleanupDir(ds, out)
+
+			base := tt.base
+			if base == "" {
+				base = strings.TrimSpace(extendsBaseTestData)
+			}
+			baseFile, err := testutils.CreateFile(ds.D1, "customers.csv", strings.Replace(base, "d2/", filepath.Base(ds.D2)+"/", 1), map[string]interface{}{})
+			assert.Nil(t, err)
+			dataSourceFile, err := testutils.CreateFile(ds.D2, "customers_renamed.csv", strings.TrimSpace(tt.content), map[string]interface{}{})
+			assert.Nil(t, err)
+			_, err = testutils.CreateFile(ds.D1, "test_snowflake.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": dataSourceFile.Name()})
+			assert.Nil(t, err)
+
+			rep, err := testutils.RunReport(logger, &formatter.Config{
+				Filetype: formatter.ParserInputTypeCsv,
+				CSV:      formatter.NewDefaultCsvConfig(),
+			}, ds.RootDir, out.RootDir)
+			assert.Nil(t, err)
+
+			message := strings.NewReplacer("${base}", baseFile.Name(), "${derived}", dataSourceFile.Name()).Replace(tt.message)
+			issues := map[string]int{}
+			for _, issue := range rep.Issues() {
+				if issue.File == dataSourceFile.Name() {
+					issues[issue.Message] = issue.Line
+				}
+			}
+			assert.Equal(t, map[string]int{message: tt.line}, issues)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+const fixturesTestData = `
+# Orders by scenario
+# fixture: on_time
+"Id[number(10,0)]","Shipped[date()]"
+1,2024-01-02
+
+# fixture: late_shipments
+"Id[number(10,0)]","Shipped[date()]","DaysLate[number(10,0)]"
+2,2024-01-20,12
+3,2024-02-01,5
+`
+
+func Test_Snowflake_Csv_Fixtures(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	_, err := testutils.CreateFile(ds.D1, "orders.csv", strings.TrimSpace(fixturesTestData), map[string]interface{}{})
+	assert.Nil(t, err)
+	onTimeTest, err := testutils.CreateFile(ds.D1, "test_on_time.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": "orders.csv#on_time"})
+	assert.Nil(t, err)
+	lateTest, err := testutils.CreateFile(ds.D1, "test_late.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": "orders.csv#late_shipments"})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, rep.DataSources)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content:    `SELECT 1::NUMBER(10,0) AS ID, '2024-01-02'::DATE AS SHIPPED`,
+	}
+	expected := testutils.Merge(t, strings.TrimSpace(errorModeTestContent), format.Values{"0": "orders.csv#on_time"}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, onTimeTest.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+
+	m2 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content: strings.TrimSpace(`
+SELECT 2::NUMBER(10,0) AS ID, '2024-01-20'::DATE AS SHIPPED, 12::NUMBER(10,0) AS DAYSLATE
+UNION ALL
+SELECT 3::NUMBER(10,0) AS ID, '2024-02-01'::DATE AS SHIPPED, 5::NUMBER(10,0) AS DAYSLATE
+`),
+	}
+	expected = testutils.Merge(t, strings.TrimSpace(errorModeTestContent), format.Values{"0": "orders.csv#late_shipments"}, m2)
+	result, err = testutils.GetGeneratorFile(out.RootDir, ds.RootDir, lateTest.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_Fixtures_Errors(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataContent := strings.Replace(strings.TrimSpace(fixturesTestData), "3,2024-02-01,5", "3,2024-02-31,5", 1)
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "orders.csv", dataContent, map[string]interface{}{})
+	assert.Nil(t, err)
+	_, err = testutils.CreateFile(ds.D1, "test_late.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": "orders.csv#late_shipments"})
+	assert.Nil(t, err)
+	_, err = testutils.CreateFile(ds.D1, "test_missing.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": "orders.csv#cancelled"})
+	assert.Nil(t, err)
+	_, err = testutils.CreateFile(ds.D1, "test_whole.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": "orders.csv"})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+
+	messages := map[string]int{}
+	for _, issue := range rep.Issues() {
+		assert.Equal(t, dataSourceFile.Name(), issue.File)
+		messages[issue.Message] = issue.Line
+	}
+	assert.Equal(t, map[string]int{
+		"error parsing value '2024-02-31' for column 'SHIPPED' in line 9":                                                                          9,
+		"fixture 'cancelled' not found in data source '" + dataSourceFile.Name() + "', expected one of 'on_time', 'late_shipments'":                0,
+		"data source '" + dataSourceFile.Name() + "' is split into the fixtures 'on_time', 'late_shipments', reference one of them with '#<name>'": 0,
+	}, messages)
+}
@@ -0,0 +1,100 @@
+package unit_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/generator"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func Test_Snowflake_Csv_Inline(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	testContent := strings.TrimSpace(`
+{{ config(tags=['unit-test']) }}
+
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+
+	{% call dbt_unit_testing.mock_ref ('<source-name>', {'inline_format': 'csv'}) %}
+		"Id[number(10,0)]",Name,"Birthday[date(MM/dd/yyyy)]"
+		1,John,12/24/1990
+		2,Jane,02/01/2000
+	{% endcall %}
+
+	{% call dbt_unit_testing.expect({'inline_format': 'csv', 'input_format': 'csv'}) %}
+		Name
+		Gunnar
+	{% endcall %}
+
+{% endcall %}
+`)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, map[string]interface{}{})
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+
+	expected := strings.TrimSpace(`
+/*###############################################
+### Do NOT modify: generated by datasourcerer ###
+###############################################*/
+{{ config(tags=['unit-test']) }}
+
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+
+	{% call dbt_unit_testing.mock_ref ('<source-name>', {'inline_format': 'csv'}) %}
+SELECT 1::NUMBER(10,0) AS ID, 'John'::VARCHAR(16777216) AS NAME, '1990-12-24'::DATE AS BIRTHDAY
+UNION ALL
+SELECT 2::NUMBER(10,0) AS ID, 'Jane'::VARCHAR(16777216) AS NAME, '2000-02-01'::DATE AS BIRTHDAY
+	{% endcall %}
+
+	{% call dbt_unit_testing.expect({'inline_format': 'csv', 'input_format': 'csv'}) %}
+NAME
+Gunnar
+	{% endcall %}
+
+{% endcall %}
+`)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_Inline_ErrorMode_Raise(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('<source-name>', {'inline_format': 'csv'}) %}
+		"Id[number(10,0)]",Name
+		one,John
+	{% endcall %}
+{% endcall %}
+`)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, map[string]interface{}{})
+	assert.Nil(t, err)
+
+	err = testutils.RunWithErrorMode(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir, generator.ErrorModeRaise)
+	assert.Nil(t, err)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 1,
+		Regex:      nil,
+		Content:    `{{ exceptions.raise_compiler_error("datasourcerer: ` + filepath.Base(testFile.Name()) + `:4: error parsing value 'one' for column 'ID' in line 4") }}`,
+	}
+	expected := testutils.Merge(t, strings.Replace(testContent, "\t\t\"Id[number(10,0)]\",Name\n\t\tone,John\n", "", 1), map[string]interface{}{}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
@@ -0,0 +1,152 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func Test_Snowflake_Csv_InputFormat_Csv(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataContent := strings.TrimSpace(`
+"Id[number(10,0)]",Name,"Active[boolean(yes,no)]","Birthday[date(02.01.2006)]"
+1,John,yes,24.12.1990
+2,"Jane, Jr.",no,01.02.2000
+	`)
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "datasource.csv", dataContent, map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.TrimSpace(`
+{{ config(tags=['unit-test']) }}
+
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+
+	{% call dbt_unit_testing.mock_ref ('<source-name>', {'source_file': '${0}', 'input_format': 'csv' }) %}
+	{% endcall %}
+
+	{% call dbt_unit_testing.expect() %}
+select 'Gunnar' as name
+	{% endcall %}
+
+{% endcall %}
+`)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content: strings.TrimSpace(`
+ID,NAME,ACTIVE,BIRTHDAY
+1,John,true,1990-12-24
+2,"Jane, Jr.",false,2000-02-01
+`),
+	}
+	expected := testutils.Merge(t, testContent, format.Values{"0": dataSourceFile.Name()}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_InputFormat_ColumnSeparator(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "datasource.csv", "\"Income[number(20,2)]\",Name\n100.10,John", map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.Replace(strings.TrimSpace(errorModeTestContent), "'${0}'", "'${0}', 'input_format': 'csv', 'column_separator': ';'", 1)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content:    "INCOME;NAME\n100.10;John",
+	}
+	expected := testutils.Merge(t, testContent, format.Values{"0": dataSourceFile.Name()}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_InputFormat_Csv_RejectsExpr(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataContent := strings.TrimSpace(`
+Id,Due[expr(DATE)]
+1,CURRENT_DATE - 1
+	`)
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "datasource.csv", dataContent, map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('<source-name>', {'source_file': '${0}', 'input_format': 'csv' }) %}
+	{% endcall %}
+{% endcall %}
+`)
+	_, err = testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+	assert.EqualError(t, err, "failed to generate 1 test file(s)")
+	assert.Len(t, rep.Issues(), 1)
+	assert.Equal(t, "column 'DUE' holds sql expressions, which cannot be written as csv. Use 'input_format': 'sql'", rep.Issues()[0].Message)
+}
+
+func Test_Snowflake_Csv_InputFormat_Csv_Null(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataContent := strings.TrimSpace(`
+"Id[number(10,0)]",Name,Nickname,"Birthday[date()]"
+1,,\N,
+	`)
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "datasource.csv", dataContent, map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('<source-name>', {'source_file': '${0}', 'input_format': 'csv' }) %}
+	{% endcall %}
+{% endcall %}
+`)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+
+	/* NULL values are written as '\N', while an empty string stays empty */
+	m1 := testutils.MergeOptions{
+		LineNumber: 1,
+		Regex:      nil,
+		Content: strings.TrimSpace(`
+ID,NAME,NICKNAME,BIRTHDAY
+1,,\N,\N
+`),
+	}
+	expected := testutils.Merge(t, testContent, format.Values{"0": dataSourceFile.Name()}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
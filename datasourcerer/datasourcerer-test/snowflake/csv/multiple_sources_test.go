@@ -0,0 +1,100 @@
+package unit_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func Test_Snowflake_Csv_MultipleSources_List(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	_, err := testutils.CreateFile(ds.D1, "base.csv", "\"Id[number(10,0)]\",Name\n1,John", map[string]interface{}{})
+	assert.Nil(t, err)
+	_, err = testutils.CreateFile(ds.D1, "edge_cases.csv", "\"Id[number(10,0)]\",Name\n2,Jane\n3,", map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.Replace(strings.TrimSpace(errorModeTestContent), "'${0}'", "${0}", 1)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": "['base.csv', 'edge_cases.csv']"})
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content: strings.TrimSpace(`
+SELECT 1::NUMBER(10,0) AS ID, 'John'::VARCHAR(16777216) AS NAME
+UNION ALL
+SELECT 2::NUMBER(10,0) AS ID, 'Jane'::VARCHAR(16777216) AS NAME
+UNION ALL
+SELECT 3::NUMBER(10,0) AS ID, ''::VARCHAR(16777216) AS NAME
+`),
+	}
+	expected := testutils.Merge(t, testContent, format.Values{"0": "['base.csv', 'edge_cases.csv']"}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_MultipleSources_GlobAsCsv(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	_, err := testutils.CreateFile(ds.D1, "orders_2.csv", "\"Id[number(10,0)]\",\"Open[boolean()]\"\n2,false", map[string]interface{}{})
+	assert.Nil(t, err)
+	_, err = testutils.CreateFile(ds.D1, "orders_1.csv", "\"Id[number(10,0)]\",\"Open[boolean()]\"\n1,true", map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.Replace(strings.TrimSpace(errorModeTestContent), "'${0}'", "'${0}', 'input_format': 'csv'", 1)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": "orders_*.csv"})
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content:    "ID,OPEN\n1,true\n2,false",
+	}
+	expected := testutils.Merge(t, testContent, format.Values{"0": "orders_*.csv"}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_MultipleSources_ColumnMismatch(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	baseFile, err := testutils.CreateFile(ds.D1, "base.csv", "\"Id[number(10,0)]\",Name\n1,John", map[string]interface{}{})
+	assert.Nil(t, err)
+	edgeFile, err := testutils.CreateFile(ds.D1, "edge_cases.csv", "\"Id[number(10,2)]\",Name\n2,Jane", map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.Replace(strings.TrimSpace(errorModeTestContent), "'${0}'", "${0}", 1)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": "['base.csv', 'edge_cases.csv']"})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+	assert.EqualError(t, err, "failed to generate 1 test file(s)")
+
+	rel, err := filepath.Rel(ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	issues := rep.Issues()
+	assert.Len(t, issues, 1)
+	assert.Equal(t, filepath.Join(out.RootDir, rel), issues[0].File)
+	assert.Equal(t, "column 1 of data source '"+edgeFile.Name()+"' is 'ID NUMBER(10,2)', but '"+baseFile.Name()+"' defines 'ID NUMBER(10,0)'", issues[0].Message)
+}
@@ -0,0 +1,98 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+const relationshipsTestContent = `
+{% call dbt_unit_testing.test('orders_summary', 'summarizes orders') %}
+	{% call dbt_unit_testing.mock_ref('orders', {'source_file': '${0}'}) %}
+	{% endcall %}
+	{% call dbt_unit_testing.mock_source('raw', 'customers', {'source_file': '${1}', 'where': "name != 'Kari'"}) %}
+	{% endcall %}
+{% endcall %}
+
+{% call dbt_unit_testing.test('orders_summary', 'without customers') %}
+	{% call dbt_unit_testing.mock_ref('orders', {'source_file': '${0}'}) %}
+	{% endcall %}
+{% endcall %}
+`
+
+const relationshipsOrdersTestData = `
+"Id[number(10,0)]","Customer_Id[number(10,0)]"
+10,1
+11,2
+12,
+13,3
+14,4
+`
+
+const relationshipsCustomersTestData = `
+"Id[number(10,0)]",Name
+1,John
+2,Jane
+3,Kari
+`
+
+func Test_Snowflake_Csv_Relationships(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+	config := &formatter.Config{
+		Filetype:      formatter.ParserInputTypeCsv,
+		CSV:           formatter.NewDefaultCsvConfig(),
+		Relationships: []string{"orders.customer_id -> raw.customers.id"},
+	}
+
+	orders, err := testutils.CreateFile(ds.D1, "orders.csv", strings.TrimSpace(relationshipsOrdersTestData), format.Values{})
+	assert.Nil(t, err)
+	customers, err := testutils.CreateFile(ds.D1, "customers.csv", strings.TrimSpace(relationshipsCustomersTestData), format.Values{})
+	assert.Nil(t, err)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", strings.TrimSpace(relationshipsTestContent), format.Values{"0": orders.Name(), "1": customers.Name()})
+	assert.Nil(t, err)
+
+	issues, checked, err := testutils.Validate(logger, config, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	// Only the test mocking both orders and customers is checked. Kari is not selected, and NULL customer ids reference nothing
+	assert.Equal(t, 1, checked)
+	assert.Equal(t, []report.Issue{
+		{
+			Stage:      report.StageValidate,
+			File:       orders.Name(),
+			Template:   testFile.Name(),
+			Line:       5,
+			ColumnName: "customer_id",
+			Value:      "3",
+			Message:    "value '3' of 'orders.customer_id' has no matching 'raw.customers.id' in the mocks of the test",
+		},
+		{
+			Stage:      report.StageValidate,
+			File:       orders.Name(),
+			Template:   testFile.Name(),
+			Line:       6,
+			ColumnName: "customer_id",
+			Value:      "4",
+			Message:    "value '4' of 'orders.customer_id' has no matching 'raw.customers.id' in the mocks of the test",
+		},
+	}, issues)
+
+	/* An unknown column cannot be checked */
+	config.Relationships = []string{"orders.client_id -> raw.customers.id"}
+	issues, checked, err = testutils.Validate(logger, config, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, checked)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, "relationship 'orders.client_id -> raw.customers.id' cannot be checked: column 'client_id' not found in mock 'orders', expected one of 'ID', 'CUSTOMER_ID'", issues[0].Message)
+	assert.Equal(t, 2, issues[0].Line)
+
+	/* An invalid relationship fails */
+	config.Relationships = []string{"orders.customer_id = customers.id"}
+	_, _, err = testutils.Validate(logger, config, ds.RootDir, out.RootDir)
+	assert.EqualError(t, err, "invalid relationship 'orders.customer_id = customers.id', expected '<mock>.<column> -> <mock>.<column>'")
+}
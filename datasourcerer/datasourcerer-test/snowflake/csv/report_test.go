@@ -0,0 +1,102 @@
+package unit_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func Test_Snowflake_Csv_Report(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	validFile, err := testutils.CreateFile(ds.D1, "valid.csv", "\"Id[number(10,0)]\",Name\n1,John", map[string]interface{}{})
+	assert.Nil(t, err)
+	malformedFile, err := testutils.CreateFile(ds.D1, "malformed.csv", "\"Income[number(20,2)]\"\n<MALFORMED>", map[string]interface{}{})
+	assert.Nil(t, err)
+
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('valid', {'source_file': '${0}' }) %}
+	{% endcall %}
+	{% call dbt_unit_testing.mock_ref ('malformed', {'source_file': '${1}' }) %}
+	{% endcall %}
+	{% call dbt_unit_testing.mock_ref ('missing', {'source_file': '${2}' }) %}
+	{% endcall %}
+{% endcall %}
+`)
+	missingFile := filepath.Join(ds.D1, "missing.csv")
+	_, err = testutils.CreateFile(ds.D1, "test_report.sql", testContent, format.Values{"0": validFile.Name(), "1": malformedFile.Name(), "2": missingFile})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+
+	/* The test file is still generated, but the run failed */
+	assert.True(t, rep.Failed())
+	assert.Equal(t, 1, rep.Templates)
+	assert.Equal(t, 3, rep.DataSources)
+	assert.Equal(t, 1, rep.Generated)
+
+	issues := map[string]report.Issue{}
+	for _, issue := range rep.Issues() {
+		issues[issue.File] = issue
+	}
+	assert.Len(t, issues, 2)
+	assert.Equal(t, report.StageParse, issues[malformedFile.Name()].Stage)
+	assert.Contains(t, issues[malformedFile.Name()].Message, "<MALFORMED>")
+	assert.Equal(t, report.StageParse, issues[missingFile].Stage)
+	assert.Contains(t, issues[missingFile].Message, "file not found")
+
+	var summary bytes.Buffer
+	assert.Nil(t, rep.WriteSummary(&summary))
+	assert.True(t, strings.HasSuffix(summary.String(), "datasourcerer failed: 1 test template(s), 3 data source(s), 1 test file(s) generated, 2 error(s)\n"))
+
+	reportPath := filepath.Join(out.RootDir, "report.json")
+	assert.Nil(t, rep.WriteJSON(reportPath))
+	content, err := os.ReadFile(reportPath)
+	assert.Nil(t, err)
+	var written struct {
+		Success bool           `json:"success"`
+		Issues  []report.Issue `json:"issues"`
+	}
+	assert.Nil(t, json.Unmarshal(content, &written))
+	assert.False(t, written.Success)
+	assert.Len(t, written.Issues, 2)
+}
+
+func Test_Snowflake_Csv_Report_Success(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	validFile, err := testutils.CreateFile(ds.D1, "valid.csv", "\"Id[number(10,0)]\",Name\n1,John", map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('valid', {'source_file': '${0}' }) %}
+	{% endcall %}
+{% endcall %}
+`)
+	_, err = testutils.CreateFile(ds.D1, "test_report.sql", testContent, format.Values{"0": validFile.Name()})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	assert.False(t, rep.Failed())
+	assert.Empty(t, rep.Issues())
+}
@@ -0,0 +1,93 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+const selectionTestData = `
+"Id[number(10,0)]",Status,"Amount[number(10,2)]"
+1,open,9.50
+2,closed,100.00
+3,open,25.00
+4,open,250.00
+`
+
+func Test_Snowflake_Csv_Selection_Sql(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "orders.csv", strings.TrimSpace(selectionTestData), map[string]interface{}{})
+	assert.Nil(t, err)
+	options := `'${0}', 'rows': '1-3', 'where': "status = 'open' and amount > 10", 'columns': ['status', 'id']`
+	testContent := strings.Replace(strings.TrimSpace(errorModeTestContent), "'${0}'", options, 1)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content:    `SELECT 'open'::VARCHAR(16777216) AS STATUS, 3::NUMBER(10,0) AS ID`,
+	}
+	expected := testutils.Merge(t, testContent, format.Values{"0": dataSourceFile.Name()}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_Selection_Csv(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "orders.csv", strings.TrimSpace(selectionTestData), map[string]interface{}{})
+	assert.Nil(t, err)
+	options := `'${0}', 'input_format': 'csv', 'where': "amount >= 100", 'columns': ['id', 'amount']`
+	testContent := strings.Replace(strings.TrimSpace(errorModeTestContent), "'${0}'", options, 1)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content:    "ID,AMOUNT\n2,100.00\n4,250.00",
+	}
+	expected := testutils.Merge(t, testContent, format.Values{"0": dataSourceFile.Name()}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_Selection_NoRows(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "orders.csv", strings.TrimSpace(selectionTestData), map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.Replace(strings.TrimSpace(errorModeTestContent), "'${0}'", `'${0}', 'where': "status = 'cancelled'"`, 1)
+	_, err = testutils.CreateFile(ds.D1, "test_snowflake.sql", testContent, format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+	assert.EqualError(t, err, "failed to generate 1 test file(s)")
+	issues := rep.Issues()
+	assert.Len(t, issues, 1)
+	assert.Equal(t, "no rows of data source '"+dataSourceFile.Name()+"' are selected, a sql mock needs at least one row", issues[0].Message)
+}
@@ -0,0 +1,55 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func Test_Snowflake_Csv_Types_Override(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	/* The override of the directory adds a type and redefines 'money', while 'email' is kept from the root config */
+	override := strings.TrimSpace(`
+filetype: csv
+csv:
+  separator: ','
+  comment: '#'
+types:
+  money: number(10,2)
+  zip:
+    sql: "'{{value}}'::POSTAL_CODE"
+`)
+	_, err := testutils.CreateFile(ds.D1, ".datasourcerer.yaml", override, map[string]interface{}{})
+	assert.Nil(t, err)
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "customers.csv", "Contact[email],Balance[money],Zip[zip]\njohn@example.com,10.5,1234", map[string]interface{}{})
+	assert.Nil(t, err)
+	testFile, err := testutils.CreateFile(ds.D1, "test_snowflake.sql", strings.TrimSpace(errorModeTestContent), format.Values{"0": dataSourceFile.Name()})
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+		Types: formatter.Types{
+			"email": {Alias: "varchar(320)"},
+			"money": {Alias: "number(19,4)"},
+		},
+	}, ds.RootDir, out.RootDir)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content: strings.TrimSpace(`
+SELECT 'john@example.com'::VARCHAR(320) AS CONTACT, 10.5::NUMBER(10,2) AS BALANCE, '1234'::POSTAL_CODE AS ZIP
+`),
+	}
+	expected := testutils.Merge(t, strings.TrimSpace(errorModeTestContent), format.Values{"0": dataSourceFile.Name()}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
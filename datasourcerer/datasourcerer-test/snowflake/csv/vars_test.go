@@ -0,0 +1,89 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+const varsTestData = `
+"Id[number(10,0)]","CustomerId[number(10,0)]",Status,Note
+1,${customer_id},{{ status }},$${kept}
+`
+
+// varsTestContent returns the test template mocking 'orders.csv' with the vars of the mock call
+func varsTestContent(vars string) string {
+	return strings.Replace(strings.TrimSpace(errorModeTestContent), "'${0}'", "'orders.csv', 'vars': "+vars, 1)
+}
+
+func Test_Snowflake_Csv_Vars(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	_, err := testutils.CreateFile(ds.D1, "orders.csv", strings.TrimSpace(varsTestData), map[string]interface{}{})
+	assert.Nil(t, err)
+	openTest, err := testutils.CreateFile(ds.D1, "test_open.sql", varsTestContent(`{'customer_id': 42}`), map[string]interface{}{})
+	assert.Nil(t, err)
+	closedTest, err := testutils.CreateFile(ds.D1, "test_closed.sql", varsTestContent(`{'customer_id': 7, 'status': 'closed'}`), map[string]interface{}{})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+		Vars:     map[string]string{"status": "open"},
+	}, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	assert.Empty(t, rep.Issues())
+	assert.Equal(t, 2, rep.DataSources)
+
+	m1 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content:    `SELECT 1::NUMBER(10,0) AS ID, 42::NUMBER(10,0) AS CUSTOMERID, 'open'::VARCHAR(16777216) AS STATUS, '${kept}'::VARCHAR(16777216) AS NOTE`,
+	}
+	expected := testutils.Merge(t, varsTestContent(`{'customer_id': 42}`), map[string]interface{}{}, m1)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, openTest.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+
+	m2 := testutils.MergeOptions{
+		LineNumber: 4,
+		Regex:      nil,
+		Content:    `SELECT 1::NUMBER(10,0) AS ID, 7::NUMBER(10,0) AS CUSTOMERID, 'closed'::VARCHAR(16777216) AS STATUS, '${kept}'::VARCHAR(16777216) AS NOTE`,
+	}
+	expected = testutils.Merge(t, varsTestContent(`{'customer_id': 7, 'status': 'closed'}`), map[string]interface{}{}, m2)
+	result, err = testutils.GetGeneratorFile(out.RootDir, ds.RootDir, closedTest.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Csv_Vars_Errors(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	dataSourceFile, err := testutils.CreateFile(ds.D1, "orders.csv", strings.TrimSpace(varsTestData), map[string]interface{}{})
+	assert.Nil(t, err)
+	_, err = testutils.CreateFile(ds.D1, "test_undefined.sql", varsTestContent(`{'status': 'open'}`), map[string]interface{}{})
+	assert.Nil(t, err)
+	_, err = testutils.CreateFile(ds.D1, "test_invalid.sql", varsTestContent(`{'customer_id': 'abc'}`), map[string]interface{}{})
+	assert.Nil(t, err)
+
+	rep, err := testutils.RunReport(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeCsv,
+		CSV:      formatter.NewDefaultCsvConfig(),
+	}, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+
+	messages := map[string]int{}
+	for _, issue := range rep.Issues() {
+		assert.Equal(t, dataSourceFile.Name(), issue.File)
+		messages[issue.Message] = issue.Line
+	}
+	assert.Equal(t, map[string]int{
+		"var 'customer_id' in line 2 is not defined, pass it in the 'vars' of the mock call or define it in 'vars' of the config": 2,
+		"error parsing value 'abc' for column 'CUSTOMERID' in line 2":                                                             2,
+	}, messages)
+}
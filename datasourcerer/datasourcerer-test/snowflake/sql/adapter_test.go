@@ -0,0 +1,89 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func Test_Snowflake_Sql_CustomMockMacros(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	firstFile, err := testutils.CreateFile(ds.D1, "first.sql", "select 'First' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+	secondFile, err := testutils.CreateFile(ds.D1, "second.sql", "select 'Second' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call my_package.mock_ref ('first', {'fixture': '${0}'}) %}
+	{% endcall %}
+	{% call dbt_unit_testing.mock_ref ('second', {'fixture': '${1}'}) %}
+	{% endcall %}
+	{% call dbt_unit_testing.mock_ref ('ignored', {'source_file': '${1}'}) %}
+	{% endcall %}
+{% endcall %}
+`)
+	formats := format.Values{"0": firstFile.Name(), "1": secondFile.Name()}
+	testFile, err := testutils.CreateFile(ds.D1, "test_adapter.sql", testContent, formats)
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeSql,
+		Mocks:    formatter.MocksConfig{Macros: []string{"my_package.mock_ref"}, SourceFileKey: "fixture"},
+	}, ds.RootDir, out.RootDir)
+
+	expected := testutils.Merge(t, testContent, formats,
+		testutils.MergeOptions{LineNumber: 1, Content: "select 'First' as name"},
+		testutils.MergeOptions{LineNumber: 3, Content: "select 'Second' as name"},
+	)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Sql_CustomExpectMacros(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	sourceFile, err := testutils.CreateFile(ds.D1, "source.sql", "select 'Source' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+	expectedFile, err := testutils.CreateFile(ds.D1, "expected.sql", "select 'Expected' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+
+	/* A package wrapping its own test, mock and expect macros is configured without a built-in profile */
+	testContent := strings.TrimSpace(`
+{% call my_package.test('<model-name>', '<test-name>') %}
+	{% call my_package.mock ('source', {'source_file': '${0}'}) %}
+	{% endcall %}
+	{% call my_package.expect ({'source_file': '${1}'}) %}
+	{% endcall %}
+{% endcall %}
+`)
+	formats := format.Values{"0": sourceFile.Name(), "1": expectedFile.Name()}
+	testFile, err := testutils.CreateFile(ds.D1, "test_adapter.sql", testContent, formats)
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{
+		Filetype: formatter.ParserInputTypeSql,
+		Mocks: formatter.MocksConfig{
+			Adapter:      "none",
+			Macros:       []string{"my_package.mock"},
+			ExpectMacros: []string{"my_package.expect"},
+			TestMacros:   []string{"my_package.test"},
+		},
+	}, ds.RootDir, out.RootDir)
+
+	expected := testutils.Merge(t, testContent, formats,
+		testutils.MergeOptions{LineNumber: 1, Content: "select 'Source' as name"},
+		testutils.MergeOptions{LineNumber: 3, Content: "select 'Expected' as name"},
+	)
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
@@ -0,0 +1,95 @@
+package unit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/generator"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func Test_Snowflake_Sql_Check(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+	config := &formatter.Config{Filetype: formatter.ParserInputTypeSql}
+
+	sourceFile, err := testutils.CreateFile(ds.D1, "source.sql", "select 'Kåre' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('source_1', {'source_file': '${0}' }) %}
+	{% endcall %}
+{% endcall %}
+`)
+	testFile, err := testutils.CreateFile(ds.D1, "test_check.sql", testContent, format.Values{"0": sourceFile.Name()})
+	assert.Nil(t, err)
+	generatedFile := filepath.Join(out.RootDir, strings.TrimPrefix(testFile.Name(), ds.RootDir))
+
+	/* Missing before the first generation */
+	checks, err := testutils.Check(logger, config, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	assert.Len(t, checks, 1)
+	assert.Equal(t, generator.FileStatusMissing, checks[0].Status)
+	_, err = os.Stat(generatedFile)
+	assert.True(t, os.IsNotExist(err))
+
+	/* Up to date after generation */
+	testutils.Run(logger, config, ds.RootDir, out.RootDir)
+	checks, err = testutils.Check(logger, config, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	assert.Len(t, checks, 1)
+	assert.Equal(t, generator.FileStatusUpToDate, checks[0].Status)
+
+	/* Stale when a data source changes */
+	assert.Nil(t, os.WriteFile(sourceFile.Name(), []byte("select 'Bjørn' as name"), 0644))
+	checks, err = testutils.Check(logger, config, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	assert.Len(t, checks, 1)
+	assert.Equal(t, generator.FileStatusStale, checks[0].Status)
+	assert.Contains(t, string(checks[0].Current), "Kåre")
+	assert.Contains(t, string(checks[0].Rendered), "Bjørn")
+
+	/* Orphaned when the test template is removed, unmanaged files are ignored */
+	_, err = testutils.CreateFile(out.D2, "handwritten.sql", "select 1", map[string]interface{}{})
+	assert.Nil(t, err)
+	testutils.DeleteFile(testFile)
+	checks, err = testutils.Check(logger, config, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	assert.Len(t, checks, 1)
+	assert.Equal(t, generator.FileStatusOrphaned, checks[0].Status)
+	assert.Equal(t, generatedFile, checks[0].Path)
+}
+
+func Test_Snowflake_Sql_Check_FailedTemplateIsNotOrphaned(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+	config := &formatter.Config{Filetype: formatter.ParserInputTypeSql}
+
+	sourceFile, err := testutils.CreateFile(ds.D1, "source.sql", "select 'Kåre' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('source_1', {'source_file': '${0}' }) %}
+	{% endcall %}
+{% endcall %}
+`)
+	testFile, err := testutils.CreateFile(ds.D1, "test_check.sql", testContent, format.Values{"0": sourceFile.Name()})
+	assert.Nil(t, err)
+	generatedFile := filepath.Join(out.RootDir, strings.TrimPrefix(testFile.Name(), ds.RootDir))
+	testutils.Run(logger, config, ds.RootDir, out.RootDir)
+	_, err = os.Stat(generatedFile)
+	assert.Nil(t, err)
+
+	/* A test template that no longer parses is skipped, but its generated test file is not orphaned */
+	assert.Nil(t, os.WriteFile(testFile.Name(), []byte("{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}"), 0644))
+	checks, err := testutils.Check(logger, config, ds.RootDir, out.RootDir)
+	assert.Nil(t, err)
+	assert.Len(t, checks, 0)
+}
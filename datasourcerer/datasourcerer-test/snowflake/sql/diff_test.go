@@ -0,0 +1,73 @@
+package unit_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func Test_Snowflake_Sql_Diff(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+	config := &formatter.Config{Filetype: formatter.ParserInputTypeSql}
+
+	sourceFile, err := testutils.CreateFile(ds.D1, "source.sql", "select 'Kåre' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('source_1', {'source_file': '${0}' }) %}
+	{% endcall %}
+{% endcall %}
+`)
+	testFile, err := testutils.CreateFile(ds.D1, "test_diff.sql", testContent, format.Values{"0": sourceFile.Name()})
+	assert.Nil(t, err)
+	rel := filepath.ToSlash(strings.TrimPrefix(testFile.Name(), ds.RootDir+string(os.PathSeparator)))
+
+	/* Created */
+	var buffer bytes.Buffer
+	summary, err := testutils.Diff(logger, config, ds.RootDir, out.RootDir, &buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, summary.Created)
+	assert.Equal(t, 1, summary.Changed())
+	assert.Contains(t, buffer.String(), "--- /dev/null\n+++ b/"+rel+"\n@@ -0,0 +1,8 @@\n")
+	_, err = os.Stat(filepath.Join(out.RootDir, rel))
+	assert.True(t, os.IsNotExist(err))
+
+	/* Unchanged */
+	testutils.Run(logger, config, ds.RootDir, out.RootDir)
+	buffer.Reset()
+	summary, err = testutils.Diff(logger, config, ds.RootDir, out.RootDir, &buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, summary.Changed())
+	assert.Equal(t, 1, summary.Unchanged)
+	assert.Empty(t, buffer.String())
+
+	/* Modified */
+	assert.Nil(t, os.WriteFile(sourceFile.Name(), []byte("select 'Bjørn' as name"), 0644))
+	buffer.Reset()
+	summary, err = testutils.Diff(logger, config, ds.RootDir, out.RootDir, &buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, summary.Modified)
+	expected := strings.TrimLeft(`
+--- a/${0}
++++ b/${0}
+@@ -3,6 +3,6 @@
+ ###############################################*/
+ {% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+ 	{% call dbt_unit_testing.mock_ref ('source_1', {'source_file': '${1}' }) %}
+-select 'Kåre' as name
++select 'Bjørn' as name
+ 	{% endcall %}
+ {% endcall %}
+\ No newline at end of file
+`, "\n")
+	assert.Equal(t, format.Formatm(expected, format.Values{"0": rel, "1": sourceFile.Name()}), buffer.String())
+}
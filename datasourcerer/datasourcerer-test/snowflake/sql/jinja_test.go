@@ -0,0 +1,77 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func Test_Snowflake_Sql_Jinja_CommentsAndWhitespaceControl(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	sourceFile, err := testutils.CreateFile(ds.D1, "source.sql", "select 'Kåre' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+
+	testContent := strings.TrimSpace(`
+{# {% call dbt_unit_testing.mock_ref ('commented', {'source_file': 'does_not_exist.sql'}) %}{% endcall %} #}
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{%- call dbt_unit_testing.mock_ref ('source_1', {'source_file': '${0}', 'comment': 'ends with %}' }) -%}
+	{%- endcall %}
+	{% call dbt_unit_testing.expect() %}
+select 'Gunnar' as name UNION ALL select 'Kåre' as name
+	{% endcall %}
+{% endcall %}
+`)
+	formats := format.Values{"0": sourceFile.Name()}
+	testFile, err := testutils.CreateFile(ds.D1, "test_jinja.sql", testContent, formats)
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{Filetype: formatter.ParserInputTypeSql}, ds.RootDir, out.RootDir)
+
+	expected := testutils.Merge(t, testContent, formats, testutils.MergeOptions{LineNumber: 2, Content: "select 'Kåre' as name"})
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func Test_Snowflake_Sql_Jinja_SeveralCallsOnOneLine(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	firstFile, err := testutils.CreateFile(ds.D1, "first.sql", "select 'First' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+	secondFile, err := testutils.CreateFile(ds.D1, "second.sql", "select 'Second' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('first', {'source_file': '${0}'}) %}{% endcall %}{% call dbt_unit_testing.mock_ref ('second', {'source_file': '${1}'}) %}{% endcall %}
+{% endcall %}
+`)
+	formats := format.Values{"0": firstFile.Name(), "1": secondFile.Name()}
+	testFile, err := testutils.CreateFile(ds.D1, "test_jinja.sql", testContent, formats)
+	assert.Nil(t, err)
+
+	testutils.Run(logger, &formatter.Config{Filetype: formatter.ParserInputTypeSql}, ds.RootDir, out.RootDir)
+
+	expected := strings.TrimSpace(format.Formatm(`
+/*###############################################
+### Do NOT modify: generated by datasourcerer ###
+###############################################*/
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('first', {'source_file': '${0}'}) %}
+select 'First' as name
+	{% endcall %}{% call dbt_unit_testing.mock_ref ('second', {'source_file': '${1}'}) %}
+select 'Second' as name
+	{% endcall %}
+{% endcall %}
+`, formats))
+	result, err := testutils.GetGeneratorFile(out.RootDir, ds.RootDir, testFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expected, result)
+}
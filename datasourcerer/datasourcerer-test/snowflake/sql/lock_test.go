@@ -0,0 +1,97 @@
+package unit_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirkon/go-format"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/generator"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+	"github.com/tsanton/dbt-unit-test-fusionizer/utilities"
+)
+
+func readLockFile(t *testing.T, lockPath string) *generator.LockFile {
+	content, err := os.ReadFile(lockPath)
+	assert.Nil(t, err)
+	lock := &generator.LockFile{}
+	assert.Nil(t, json.Unmarshal(content, lock))
+	return lock
+}
+
+func Test_Snowflake_Sql_LockFile(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+	config := &formatter.Config{Filetype: formatter.ParserInputTypeSql}
+	lockPath := filepath.Join(ds.RootDir, ".datasourcerer.lock")
+
+	sourceFile, err := testutils.CreateFile(ds.D1, "source.sql", "select 'Kåre' as name", map[string]interface{}{})
+	assert.Nil(t, err)
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.mock_ref ('source_1', {'source_file': '${0}' }) %}
+	{% endcall %}
+{% endcall %}
+`)
+	testFile, err := testutils.CreateFile(ds.D1, "test_lock.sql", testContent, format.Values{"0": sourceFile.Name()})
+	assert.Nil(t, err)
+	key := filepath.ToSlash(strings.TrimPrefix(testFile.Name(), ds.RootDir+string(os.PathSeparator)))
+	generatedFile := filepath.Join(out.RootDir, key)
+
+	/* The first generation records the hashes */
+	assert.Nil(t, testutils.RunLocked(logger, config, ds.RootDir, out.RootDir, lockPath, "1.0.0"))
+	generated, err := os.ReadFile(generatedFile)
+	assert.Nil(t, err)
+	lock := readLockFile(t, lockPath)
+	assert.Equal(t, "1.0.0", lock.Version)
+	assert.Len(t, lock.Tests, 1)
+	entry := lock.Tests[key]
+	assert.Equal(t, utilities.Sha256(generated), entry.Output)
+	sourceKey := filepath.ToSlash(strings.TrimPrefix(sourceFile.Name(), ds.RootDir+string(os.PathSeparator)))
+	assert.Equal(t, utilities.Sha256([]byte("select 'Kåre' as name")), entry.Fixtures[sourceKey].Content)
+
+	/* Timestamps do not matter: an older test file with matching hashes is not regenerated */
+	handEdited := append(generated, []byte("-- kept\n")...)
+	assert.Nil(t, os.WriteFile(generatedFile, handEdited, 0644))
+	assert.Nil(t, os.Chtimes(generatedFile, time.Unix(0, 0), time.Unix(0, 0)))
+	entry.Output = utilities.Sha256(handEdited)
+	lock.Tests[key] = entry
+	content, err := json.Marshal(lock)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(lockPath, content, 0644))
+	assert.Nil(t, testutils.RunLocked(logger, config, ds.RootDir, out.RootDir, lockPath, "1.0.0"))
+	result, err := os.ReadFile(generatedFile)
+	assert.Nil(t, err)
+	assert.Equal(t, string(handEdited), string(result))
+
+	/* A new tool version regenerates */
+	assert.Nil(t, testutils.RunLocked(logger, config, ds.RootDir, out.RootDir, lockPath, "1.0.1"))
+	result, err = os.ReadFile(generatedFile)
+	assert.Nil(t, err)
+	assert.Equal(t, string(generated), string(result))
+
+	/* A changed output regenerates */
+	assert.Nil(t, os.WriteFile(generatedFile, []byte("edited"), 0644))
+	assert.Nil(t, testutils.RunLocked(logger, config, ds.RootDir, out.RootDir, lockPath, "1.0.1"))
+	result, err = os.ReadFile(generatedFile)
+	assert.Nil(t, err)
+	assert.Equal(t, string(generated), string(result))
+
+	/* A changed fixture regenerates, even when its modification time is in the past */
+	assert.Nil(t, os.WriteFile(sourceFile.Name(), []byte("select 'Bjørn' as name"), 0644))
+	assert.Nil(t, os.Chtimes(sourceFile.Name(), time.Unix(0, 0), time.Unix(0, 0)))
+	assert.Nil(t, testutils.RunLocked(logger, config, ds.RootDir, out.RootDir, lockPath, "1.0.1"))
+	result, err = os.ReadFile(generatedFile)
+	assert.Nil(t, err)
+	assert.Contains(t, string(result), "Bjørn")
+
+	/* Deleted templates are dropped from the lock */
+	testutils.DeleteFile(testFile)
+	assert.Nil(t, testutils.RunLocked(logger, config, ds.RootDir, out.RootDir, lockPath, "1.0.1"))
+	assert.Len(t, readLockFile(t, lockPath).Tests, 0)
+}
@@ -0,0 +1,84 @@
+package unit_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func Test_Snowflake_Sql_Prune(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+	config := &formatter.Config{Filetype: formatter.ParserInputTypeSql}
+
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.expect() %}
+select 'Gunnar' as name
+	{% endcall %}
+{% endcall %}
+`)
+	keptFile, err := testutils.CreateFile(ds.D1, "test_kept.sql", testContent, map[string]interface{}{})
+	assert.Nil(t, err)
+	removedFile, err := testutils.CreateFile(ds.D2, "test_removed.sql", testContent, map[string]interface{}{})
+	assert.Nil(t, err)
+	testutils.Run(logger, config, ds.RootDir, out.RootDir)
+
+	generated := func(f *os.File) string {
+		return filepath.Join(out.RootDir, strings.TrimPrefix(f.Name(), ds.RootDir))
+	}
+	handwritten, err := testutils.CreateFile(filepath.Dir(generated(keptFile)), "test_handwritten.sql", "select 1", map[string]interface{}{})
+	assert.Nil(t, err)
+	testutils.DeleteFile(removedFile)
+
+	/* Dry run lists without deleting */
+	orphans, err := testutils.Prune(logger, config, ds.RootDir, out.RootDir, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{generated(removedFile)}, orphans)
+	_, err = os.Stat(generated(removedFile))
+	assert.Nil(t, err)
+
+	/* Prune deletes the orphan and its empty directory, but not handwritten tests */
+	orphans, err = testutils.Prune(logger, config, ds.RootDir, out.RootDir, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{generated(removedFile)}, orphans)
+	_, err = os.Stat(generated(removedFile))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Dir(generated(removedFile)))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(generated(keptFile))
+	assert.Nil(t, err)
+	_, err = os.Stat(handwritten.Name())
+	assert.Nil(t, err)
+}
+
+func Test_Snowflake_Sql_Prune_RefusesWhenTheCrawlFailed(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+	config := &formatter.Config{Filetype: formatter.ParserInputTypeSql}
+
+	testContent := strings.TrimSpace(`
+{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}
+	{% call dbt_unit_testing.expect() %}
+select 'Gunnar' as name
+	{% endcall %}
+{% endcall %}
+`)
+	testFile, err := testutils.CreateFile(ds.D1, "test_broken.sql", testContent, map[string]interface{}{})
+	assert.Nil(t, err)
+	testutils.Run(logger, config, ds.RootDir, out.RootDir)
+	generated := filepath.Join(out.RootDir, strings.TrimPrefix(testFile.Name(), ds.RootDir))
+
+	/* A test template that no longer parses must not get its generated test deleted */
+	assert.Nil(t, os.WriteFile(testFile.Name(), []byte("{% call dbt_unit_testing.test('<model-name>', '<test-name>') %}"), 0644))
+	orphans, err := testutils.Prune(logger, config, ds.RootDir, out.RootDir, false)
+	assert.ErrorContains(t, err, "refusing to prune")
+	assert.Empty(t, orphans)
+	_, err = os.Stat(generated)
+	assert.Nil(t, err)
+}
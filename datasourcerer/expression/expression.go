@@ -0,0 +1,186 @@
+package expression
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// expressionRegex matches a cell expression: '=<function>', optionally with '(<arguments>)' and offsets such as '-3d' or '+1mo'
+var expressionRegex = regexp.MustCompile(`^=\s*(today|now|uuid|seq)\b\s*(?:\(([^)]*)\))?\s*(.*?)\s*$`)
+
+// offsetRegex matches an offset of a relative date or time, e.g. '-3d', '+ 2 w' or '-90min'
+var offsetRegex = regexp.MustCompile(`^([+-])\s*(\d+)\s*(min|mo|s|h|d|w|y)\s*`)
+
+// Evaluator evaluates the cell expressions of a data source: '=today-3d', '=now+2h', '=uuid()' and '=seq(1000)'.
+// Dates are relative to a pinned reference time and uuids are drawn from a seeded generator, so that the output is reproducible
+type Evaluator struct {
+	reference time.Time
+	seed      int64
+	source    string             // The data source of the cells, mixed into the seed of the uuids
+	random    map[int]*rand.Rand // The uuid generator of each column
+	sequences map[int]*sequence  // The sequence of each column
+}
+
+// sequence is the next value and the step of the sequence of a column
+type sequence struct {
+	next int64
+	step int64
+}
+
+func NewEvaluator(reference time.Time, seed int64) *Evaluator {
+	return &Evaluator{
+		reference: reference,
+		seed:      seed,
+		random:    map[int]*rand.Rand{},
+		sequences: map[int]*sequence{},
+	}
+}
+
+// WithSource sets the data source of the cells, e.g. 'orders.csv#late', so that data sources sharing a seed get different uuids
+func (e *Evaluator) WithSource(source string) *Evaluator {
+	e.source = source
+	return e
+}
+
+// ParseReference parses the pinned reference time of the expressions, a date such as '2024-01-31' or an RFC 3339 timestamp.
+// An empty reference is the current time, and the expressions then change with the day of generation
+func ParseReference(reference string) (time.Time, error) {
+	if reference == "" {
+		return time.Now().UTC(), nil
+	}
+	if t, err := time.Parse("2006-01-02", reference); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, reference)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid reference date '%s', expected a date such as '2024-01-31' or a timestamp such as '2024-01-31T08:00:00Z'", reference)
+	}
+	return t, nil
+}
+
+// Evaluate returns the value of a cell of the column (0-based): a time.Time for 'today' and 'now', a string for 'uuid()' and an int64 for 'seq()'.
+// Values that are not expressions are returned unchanged, and '==' escapes a value starting with '='
+func (e *Evaluator) Evaluate(column int, value string) (interface{}, error) {
+	if strings.HasPrefix(value, "==") {
+		return value[1:], nil
+	}
+	match := expressionRegex.FindStringSubmatch(value)
+	if match == nil {
+		return value, nil
+	}
+	function, args, offsets := match[1], strings.TrimSpace(match[2]), match[3]
+
+	switch function {
+	case "today", "now":
+		if args != "" {
+			return nil, fmt.Errorf("'%s' takes no arguments", function)
+		}
+		t := e.reference
+		if function == "today" {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		}
+		return applyOffsets(function, t, offsets)
+	case "uuid":
+		if args != "" || offsets != "" {
+			return nil, fmt.Errorf("'uuid()' takes no arguments or offsets")
+		}
+		random, ok := e.random[column]
+		if !ok {
+			random = rand.New(rand.NewSource(e.uuidSeed(column)))
+			e.random[column] = random
+		}
+		id, err := uuid.NewRandomFromReader(random)
+		if err != nil {
+			return nil, fmt.Errorf("error generating uuid: %w", err)
+		}
+		return id.String(), nil
+	default:
+		if offsets != "" {
+			return nil, fmt.Errorf("'seq()' takes no offsets")
+		}
+		return e.nextInSequence(column, args)
+	}
+}
+
+// uuidSeed mixes the seed, the data source and the column (0-based), so that every column of every data source draws its own uuids
+func (e *Evaluator) uuidSeed(column int) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%s:%d", e.seed, e.source, column)
+	return int64(h.Sum64())
+}
+
+// nextInSequence returns the next value of the sequence of the column, started by its first 'seq(<start>, <step>)'. It starts at 1 with step 1 by default
+func (e *Evaluator) nextInSequence(column int, args string) (int64, error) {
+	start, step := int64(1), int64(1)
+	if args != "" {
+		params := strings.Split(args, ",")
+		if len(params) > 2 {
+			return 0, fmt.Errorf("'seq()' takes a start and an optional step, got '%s'", args)
+		}
+		values := make([]int64, len(params))
+		for i, param := range params {
+			value, err := strconv.ParseInt(strings.TrimSpace(param), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("'seq()' takes integer arguments, got '%s'", strings.TrimSpace(param))
+			}
+			values[i] = value
+		}
+		start = values[0]
+		if len(values) == 2 {
+			step = values[1]
+		}
+	}
+
+	seq, ok := e.sequences[column]
+	if !ok {
+		seq = &sequence{next: start, step: step}
+		e.sequences[column] = seq
+	}
+	value := seq.next
+	seq.next += seq.step
+	return value, nil
+}
+
+// applyOffsets adds the offsets, e.g. '-3d+12h', to the time. 'today' only takes offsets of days, weeks, months and years
+func applyOffsets(function string, t time.Time, offsets string) (time.Time, error) {
+	rest := offsets
+	for rest != "" {
+		match := offsetRegex.FindStringSubmatch(rest)
+		if match == nil {
+			return time.Time{}, fmt.Errorf("invalid offset '%s', expected e.g. '-3d' with the unit s, min, h, d, w, mo or y", rest)
+		}
+		rest = rest[len(match[0]):]
+		n, err := strconv.Atoi(match[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid offset '%s%s%s'", match[1], match[2], match[3])
+		}
+		if match[1] == "-" {
+			n = -n
+		}
+
+		switch match[3] {
+		case "s", "min", "h":
+			if function == "today" {
+				return time.Time{}, fmt.Errorf("'today' takes offsets in d, w, mo or y, use 'now' for '%s'", match[3])
+			}
+			unit := map[string]time.Duration{"s": time.Second, "min": time.Minute, "h": time.Hour}[match[3]]
+			t = t.Add(time.Duration(n) * unit)
+		case "d":
+			t = t.AddDate(0, 0, n)
+		case "w":
+			t = t.AddDate(0, 0, 7*n)
+		case "mo":
+			t = t.AddDate(0, n, 0)
+		case "y":
+			t = t.AddDate(n, 0, 0)
+		}
+	}
+	return t, nil
+}
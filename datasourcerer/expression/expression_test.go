@@ -0,0 +1,103 @@
+package expression_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/expression"
+)
+
+func Test_Evaluator_Evaluate(t *testing.T) {
+	reference := time.Date(2024, 1, 31, 8, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		value    string
+		expected interface{}
+		err      string
+	}{
+		{name: "literal", value: "John", expected: "John"},
+		{name: "literal with equals", value: "=nowhere", expected: "=nowhere"},
+		{name: "escaped", value: "==today", expected: "=today"},
+		{name: "today", value: "=today", expected: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "today minus days", value: "=today-3d", expected: time.Date(2024, 1, 28, 0, 0, 0, 0, time.UTC)},
+		{name: "today plus month", value: "=today+1mo", expected: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "today offsets", value: "=today() - 1y + 2w", expected: time.Date(2023, 2, 14, 0, 0, 0, 0, time.UTC)},
+		{name: "now", value: "=now", expected: reference},
+		{name: "now minus minutes", value: "=now-90min", expected: time.Date(2024, 1, 31, 7, 0, 0, 0, time.UTC)},
+		{name: "seq", value: "=seq(1000)", expected: int64(1000)},
+		{name: "today with hours", value: "=today-2h", err: "'today' takes offsets in d, w, mo or y, use 'now' for 'h'"},
+		{name: "invalid offset", value: "=today-3days", err: "invalid offset 'ays', expected e.g. '-3d' with the unit s, min, h, d, w, mo or y"},
+		{name: "today arguments", value: "=today(1)", err: "'today' takes no arguments"},
+		{name: "uuid arguments", value: "=uuid(4)", err: "'uuid()' takes no arguments or offsets"},
+		{name: "seq arguments", value: "=seq(a)", err: "'seq()' takes integer arguments, got 'a'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := expression.NewEvaluator(reference, 0).Evaluate(0, tt.value)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func Test_Evaluator_Sequence(t *testing.T) {
+	evaluator := expression.NewEvaluator(time.Now(), 0)
+	var values []interface{}
+	for _, value := range []string{"=seq(10, 5)", "=seq(10, 5)", "=seq()", "=seq(10, 5)"} {
+		v, err := evaluator.Evaluate(0, value)
+		assert.Nil(t, err)
+		values = append(values, v)
+	}
+	assert.Equal(t, []interface{}{int64(10), int64(15), int64(20), int64(25)}, values)
+
+	/* Every column has its own sequence */
+	v, err := evaluator.Evaluate(1, "=seq()")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), v)
+}
+
+func Test_Evaluator_Uuid(t *testing.T) {
+	generate := func(seed int64) []interface{} {
+		evaluator := expression.NewEvaluator(time.Now(), seed)
+		var values []interface{}
+		for i := 0; i < 3; i++ {
+			v, err := evaluator.Evaluate(0, "=uuid()")
+			assert.Nil(t, err)
+			values = append(values, v)
+		}
+		return values
+	}
+	values := generate(42)
+	assert.Equal(t, values, generate(42))
+	assert.NotEqual(t, values, generate(7))
+	assert.NotEqual(t, values[0], values[1])
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, values[0])
+
+	/* Data sources sharing a seed get different uuids */
+	orders, err := expression.NewEvaluator(time.Now(), 42).WithSource("orders.csv").Evaluate(0, "=uuid()")
+	assert.Nil(t, err)
+	late, err := expression.NewEvaluator(time.Now(), 42).WithSource("orders.csv#late").Evaluate(0, "=uuid()")
+	assert.Nil(t, err)
+	assert.NotEqual(t, orders, late)
+	again, err := expression.NewEvaluator(time.Now(), 42).WithSource("orders.csv").Evaluate(0, "=uuid()")
+	assert.Nil(t, err)
+	assert.Equal(t, orders, again)
+}
+
+func Test_ParseReference(t *testing.T) {
+	reference, err := expression.ParseReference("2024-01-31")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), reference)
+
+	reference, err = expression.ParseReference("2024-01-31T08:00:00+01:00")
+	assert.Nil(t, err)
+	assert.True(t, reference.Equal(time.Date(2024, 1, 31, 7, 0, 0, 0, time.UTC)))
+
+	_, err = expression.ParseReference("31.01.2024")
+	assert.EqualError(t, err, "invalid reference date '31.01.2024', expected a date such as '2024-01-31' or a timestamp such as '2024-01-31T08:00:00Z'")
+}
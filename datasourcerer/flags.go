@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/generator"
 	"gopkg.in/yaml.v3"
 )
 
@@ -32,11 +33,20 @@ type mainConfig struct {
 	templateDir string
 	unitTestDir string
 	configPath  string
+	lockPath    string
+	reportPath  string
+	onError     string
+	diagnostics string
+	errorMode   generator.ErrorMode
 	workers     int
 	crawlers    int
 	parsers     int
 	generators  int
 	cases       Cases
+	check       bool
+	diff        bool
+	prune       bool
+	dryRun      bool
 	config      formatter.Config
 }
 
@@ -45,12 +55,20 @@ func configureFlags() *mainConfig {
 	flag.StringVar(&cfg.templateDir, "template-dir", "", "The directory to read template files from. Defaults to <DBT_PROJECT_DIR>/test-templates/")
 	flag.StringVar(&cfg.unitTestDir, "test-dir", "", "The directory to output templated files. Defaults to <DBT_PROJECT_DIR>/tests/unit/")
 	flag.StringVar(&cfg.configPath, "config-file", "", "The path to the datasourcerer config file. Defaults to <DBT_PROJECT_DIR>/.datasourcerer.yaml")
+	flag.StringVar(&cfg.lockPath, "lock-file", "", "The path to the lock file recording the content hashes of generated tests. Defaults to .datasourcerer.lock next to the config file")
+	flag.StringVar(&cfg.reportPath, "report-file", "", "Write a JSON report with every crawler, parser and generator error to this path. Disabled by default")
+	flag.StringVar(&cfg.onError, "on-error", string(generator.ErrorModeInline), "How to render data sources that fail to parse: 'inline' writes the error into the mock, 'raise' fails the dbt compilation with the error and 'strict' does not write the test file. Defaults to 'inline'")
+	flag.StringVar(&cfg.diagnostics, "diagnostics-format", "text", "How to print the errors of the run: 'text' for a human readable summary, 'json' for a SARIF log. Defaults to 'text'")
 	flag.IntVar(&cfg.workers, "workers", 10, "How many workers to use. Will override the 'crawlers', 'parsers' and 'generators' flags. Defaults to 10")
 	flag.IntVar(&cfg.crawlers, "crawlers", 10, "How many crawlers to run in parallell when parsing test templates, Defaults to 10")
 	flag.IntVar(&cfg.parsers, "parsers", 10, "How many parsers to run in parallell when parsing and formatting data sources. Defaults to 10")
 	flag.IntVar(&cfg.generators, "generators", 10, "How many generator to run in parallell when merging and outputting formatted data sources and test templates. Defaults to 10")
-	flag.Var(&cfg.cases, "case", "file name to target for test case parsing")
-	flag.Var(&cfg.cases, "c", "file name to target for test case parsing")
+	flag.Var(&cfg.cases, "case", "file name, glob or model name to target for test case parsing. Can be repeated")
+	flag.Var(&cfg.cases, "c", "file name, glob or model name to target for test case parsing. Can be repeated")
+	flag.BoolVar(&cfg.prune, "prune", false, "Delete generated tests in the test directory whose test template no longer exists. Cannot be combined with 'case', and refuses to run when a test template fails to crawl")
+	flag.BoolVar(&cfg.dryRun, "dry-run", false, "Together with 'prune': only list the generated tests that would be deleted")
+	flag.BoolVar(&cfg.diff, "diff", false, "Print a unified diff of the changes a generation would make to the generated tests without writing anything")
+	flag.BoolVar(&cfg.check, "check", false, "Verify that the generated tests are up to date without writing anything. Exits non-zero when a test file is stale, missing or orphaned")
 	return cfg
 }
 
@@ -166,6 +184,44 @@ func (r *mainConfig) configureDefaults(logger *slog.Logger) error {
 		// Ensure the 'configPath' is cleaned up
 		r.configPath = path.Clean(r.configPath)
 	}
+
+	//lock-file
+	if r.lockPath == "" {
+		r.lockPath = path.Join(filepath.Dir(r.configPath), ".datasourcerer.lock")
+		logger.Debug(fmt.Sprintf("Using '%s' as the lock file location", r.lockPath))
+	} else if !filepath.IsAbs(r.lockPath) {
+		if dbtProjectDir != "" {
+			r.lockPath = path.Join(dbtProjectDir, r.lockPath)
+		} else {
+			r.lockPath = path.Join(executablePath, r.lockPath)
+		}
+		logger.Debug(fmt.Sprintf("Relative 'lock-file' flag provided. Using lock file at '%s'", r.lockPath))
+	}
+
+	//on-error
+	errorMode, errorModeErr := generator.ParseErrorMode(r.onError)
+	if errorModeErr != nil {
+		logger.Error(errorModeErr.Error())
+		return errorModeErr
+	}
+	r.errorMode = errorMode
+
+	//diagnostics-format
+	if r.diagnostics != "text" && r.diagnostics != "json" {
+		diagnosticsErr := fmt.Errorf("unknown diagnostics format '%s', expected 'text' or 'json'", r.diagnostics)
+		logger.Error(diagnosticsErr.Error())
+		return diagnosticsErr
+	}
+
+	//report-file
+	if r.reportPath != "" && !filepath.IsAbs(r.reportPath) {
+		if dbtProjectDir != "" {
+			r.reportPath = path.Join(dbtProjectDir, r.reportPath)
+		} else {
+			r.reportPath = path.Join(executablePath, r.reportPath)
+		}
+		logger.Debug(fmt.Sprintf("Relative 'report-file' flag provided. Writing report to '%s'", r.reportPath))
+	}
 	return err
 }
 
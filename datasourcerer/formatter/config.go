@@ -1,15 +1,35 @@
 package formatter
 
 type Config struct {
-	Dialect  string          `yaml:"dialect"`
-	Filetype ParserInputType `yaml:"filetype"`
-	CSV      CsvConfig       `yaml:"csv"`
+	Dialect       string            `yaml:"dialect"`
+	Filetype      ParserInputType   `yaml:"filetype"`
+	CSV           CsvConfig         `yaml:"csv"`
+	Mocks         MocksConfig       `yaml:"mocks"`
+	Vars          map[string]string `yaml:"vars"`          //Values of the '${name}' and '{{ name }}' placeholders in the data sources. The 'vars' of a mock call take precedence
+	Relationships []string          `yaml:"relationships"` //Foreign keys between the mocks of a test, e.g. 'orders.customer_id -> customers.id', checked by 'datasourcerer validate'
+	Types         Types             `yaml:"types"`         //Column type aliases, e.g. 'email: varchar(320)', and custom types with a regex and a sql template, e.g. "'{{value}}'::MY_DOMAIN"
+}
+
+type MocksConfig struct {
+	Adapter       string   `yaml:"adapter"`       //Built-in profile of the dbt mocking package the test templates use. 'dbt_unit_testing' by default
+	Macros        []string `yaml:"macros"`        //Additional macros whose call blocks mock a model or source, e.g. 'my_package.mock_ref'
+	ExpectMacros  []string `yaml:"expectMacros"`  //Additional macros whose call blocks are the expected output of a test, e.g. 'my_package.expect'
+	TestMacros    []string `yaml:"testMacros"`    //Additional macros whose first argument is the tested model, e.g. 'my_package.test'. Selects the cases by model name
+	SourceFileKey string   `yaml:"sourceFileKey"` //The option key of a mock call referencing its data source. Defaults to the key of the adapter, 'source_file'
 }
 
 type CsvConfig struct {
-	Separator        string `yaml:"separator"`        //This is the field delimiter. It's set to a comma (,) by default
-	Comment          string `yaml:"comment"`          //This is the comment character. Lines beginning with this character are ignored. '#' by default
-	TrimLeadingSpace bool   `yaml:"trimLeadingSpace"` //Trim leading space flag. Defaults to true
+	Separator        string            `yaml:"separator"`        //This is the field delimiter. It's set to a comma (,) by default
+	Comment          string            `yaml:"comment"`          //This is the comment character. Lines beginning with this character are ignored. '#' by default
+	TrimLeadingSpace bool              `yaml:"trimLeadingSpace"` //Trim leading space flag. Defaults to true
+	Expressions      ExpressionsConfig `yaml:"expressions"`      //Evaluation of the cell expressions such as '=today-3d', '=uuid()' and '=seq(1000)'
+}
+
+type ExpressionsConfig struct {
+	ReferenceDate string `yaml:"referenceDate"` //The date '=today' and '=now' are relative to, e.g. '2024-01-31' or '2024-01-31T08:00:00Z'. The current time when empty, so the generated tests then change every day
+	Seed          int64  `yaml:"seed"`          //The seed of the '=uuid()' values. 0 by default
+	KeepText      bool   `yaml:"keepText"`      //Keep the values of text columns as they are, so that a text such as '=today' is not evaluated. Off by default: '=uuid()' ids are evaluated in text columns, and '==' escapes a text starting with '='
+	Source        string `yaml:"-" json:"-"`    //The data source the expressions are evaluated in, set by the parser. Mixed into the seed, so that every data source gets its own uuids
 }
 
 func (s *CsvConfig) Validate() bool {
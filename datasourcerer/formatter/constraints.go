@@ -0,0 +1,161 @@
+package formatter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var _ ICsvHeader = &ConstrainedHeader{}
+
+// ConstrainedHeader is a column with constraints after the type of its annotated header, e.g. 'status[varchar(10)|default=open|enum=open,closed]'.
+// The constraints are checked against the normalized values of the column, and NULL values only violate 'not_null'
+type ConstrainedHeader struct {
+	ICsvHeader
+	notNull      bool
+	unique       bool
+	defaultValue *string
+	enum         []string
+	min          *string
+	max          *string
+	seen         map[string]int // The line of every value of a unique column
+}
+
+// SplitConstraints splits an annotated header such as 'id[number(38,0)|not_null|unique]' into the header without constraints, 'id[number(38,0)]', and its constraints
+func SplitConstraints(header string) (string, []string) {
+	trimmed := strings.TrimSpace(header)
+	open := strings.Index(trimmed, "[")
+	if open < 0 || !strings.HasSuffix(trimmed, "]") {
+		return header, nil
+	}
+	end := strings.Index(trimmed[open:], ")|")
+	if end < 0 {
+		return header, nil
+	}
+	end += open + 1
+	return trimmed[:end] + "]", strings.Split(trimmed[end+1:len(trimmed)-1], "|")
+}
+
+// NewConstrainedHeader returns the column of the header with the constraints 'not_null', 'unique', 'default=<value>', 'enum=<value>,...', 'min=<value>' and 'max=<value>'.
+// The enum values and bounds must be valid values of the column
+func NewConstrainedHeader(header ICsvHeader, constraints []string) (*ConstrainedHeader, error) {
+	h := &ConstrainedHeader{ICsvHeader: header}
+	for _, constraint := range constraints {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(constraint), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if hasValue != (name == "default" || name == "enum" || name == "min" || name == "max") {
+			return nil, fmt.Errorf("unknown constraint '%s' of column '%s', expected not_null, unique, default=<value>, enum=<value>,..., min=<value> or max=<value>", constraint, header.GetName())
+		}
+
+		switch name {
+		case "not_null":
+			h.notNull = true
+		case "unique":
+			h.unique = true
+			h.seen = map[string]int{}
+		case "default":
+			h.defaultValue = &value
+		case "enum":
+			for _, v := range strings.Split(value, ",") {
+				normalized, err := h.normalize(name, strings.TrimSpace(v))
+				if err != nil {
+					return nil, err
+				}
+				h.enum = append(h.enum, normalized)
+			}
+		case "min", "max":
+			normalized, err := h.normalize(name, strings.TrimSpace(value))
+			if err != nil {
+				return nil, err
+			}
+			if name == "min" {
+				h.min = &normalized
+			} else {
+				h.max = &normalized
+			}
+		default:
+			return nil, fmt.Errorf("unknown constraint '%s' of column '%s', expected not_null, unique, default=<value>, enum=<value>,..., min=<value> or max=<value>", constraint, header.GetName())
+		}
+	}
+	if h.min != nil && h.max != nil && compare(*h.min, *h.max) > 0 {
+		return nil, fmt.Errorf("min '%s' of column '%s' is greater than its max '%s'", *h.min, header.GetName(), *h.max)
+	}
+	return h, nil
+}
+
+// normalize returns a value of a constraint in the normalized form of the column
+func (h *ConstrainedHeader) normalize(constraint string, value string) (string, error) {
+	normalized, err := h.GetCsvWriter()(value)
+	if err != nil {
+		return "", fmt.Errorf("%s '%s' of column '%s' is not a valid value of the column: %w", constraint, value, h.GetName(), err)
+	}
+	return normalized, nil
+}
+
+// Default returns the default value of the column for an empty value, and the value otherwise
+func (h *ConstrainedHeader) Default(value string) string {
+	if value == "" && h.defaultValue != nil {
+		return *h.defaultValue
+	}
+	return value
+}
+
+// CheckNull returns an error if the column is 'not_null'
+func (h *ConstrainedHeader) CheckNull(line int) error {
+	if h.notNull {
+		return fmt.Errorf("value of column '%s' in line %d is NULL, but the column is not_null", h.GetName(), line)
+	}
+	return nil
+}
+
+// Check returns an error if the normalized value violates the 'unique', 'enum', 'min' or 'max' constraint of the column
+func (h *ConstrainedHeader) Check(value string, line int) error {
+	if len(h.enum) > 0 {
+		found := false
+		for _, v := range h.enum {
+			found = found || v == value
+		}
+		if !found {
+			return fmt.Errorf("value '%s' of column '%s' in line %d is not one of the enum values %s", value, h.GetName(), line, strings.Join(h.enum, ", "))
+		}
+	}
+	if h.min != nil && compare(value, *h.min) < 0 {
+		return fmt.Errorf("value '%s' of column '%s' in line %d is less than the min %s", value, h.GetName(), line, *h.min)
+	}
+	if h.max != nil && compare(value, *h.max) > 0 {
+		return fmt.Errorf("value '%s' of column '%s' in line %d is greater than the max %s", value, h.GetName(), line, *h.max)
+	}
+	if h.unique {
+		if first, ok := h.seen[value]; ok {
+			return fmt.Errorf("value '%s' of column '%s' in line %d is not unique, it is also in line %d", value, h.GetName(), line, first)
+		}
+		h.seen[value] = line
+	}
+	return nil
+}
+
+// compare compares two normalized values, numerically when both are numbers and as text otherwise.
+// The normalized dates and timestamps are in sortable formats, e.g. '2006-01-02'
+func compare(a string, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// UnwrapHeader returns the column of a header without its constraints
+func UnwrapHeader(header ICsvHeader) ICsvHeader {
+	if constrained, ok := header.(*ConstrainedHeader); ok {
+		return constrained.ICsvHeader
+	}
+	return header
+}
@@ -3,7 +3,13 @@ package formatter
 type ICsvHeader interface {
 	GetName() string
 
+	// GetType returns the sql type of the column, e.g. 'NUMBER(10,2)'
+	GetType() string
+
 	GetWriter() func(value interface{}) ([]byte, error)
 
+	// GetCsvWriter validates a value and returns it normalized for CSV input, e.g. dates in the default date format
+	GetCsvWriter() func(value interface{}) (string, error)
+
 	ParseHeader(signature string) error
 }
@@ -7,4 +7,7 @@ import (
 type IDataSourceFormatter interface {
 	Read(r io.Reader) error
 	Write(writer io.Writer) error
+
+	// Table returns the parsed rows and columns of the data source, or nil when they are not known, e.g. for sql files
+	Table() *Table
 }
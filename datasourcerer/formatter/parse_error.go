@@ -0,0 +1,46 @@
+package formatter
+
+import (
+	"encoding/csv"
+	"errors"
+)
+
+// ParseError is returned by the readers when a data source is malformed, and locates the error in the data source
+type ParseError struct {
+	Line        int    // 1-based line number in the data source, 0 if unknown
+	Column      int    // 1-based byte column of the offending field in the line, 0 if unknown
+	ColumnIndex int    // 1-based index of the offending CSV column, 0 if the error is not tied to a column
+	ColumnName  string // Name of the offending CSV column, empty for header errors
+	Value       string // The offending header or value
+	Err         error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// AsParseError returns the location of an error raised while reading a data source.
+// Errors from encoding/csv are converted, and nil is returned if the error is not located
+func AsParseError(err error) *ParseError {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr
+	}
+	var csvErr *csv.ParseError
+	if errors.As(err, &csvErr) {
+		return &ParseError{Line: csvErr.Line, Column: csvErr.Column, Err: err}
+	}
+	return nil
+}
+
+// ErrorLine returns the line in the data source an error was raised on, or 0 if the error is not located
+func ErrorLine(err error) int {
+	if parseErr := AsParseError(err); parseErr != nil {
+		return parseErr.Line
+	}
+	return 0
+}
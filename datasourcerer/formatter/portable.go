@@ -0,0 +1,41 @@
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// portableTypeRegex matches an annotated header with a portable type, e.g. 'amount[decimal(10,2)]' or 'id[int|not_null]'.
+// Only 'decimal' takes arguments, so that the portable types do not shadow the dialect types, e.g. postgres 'int()' or snowflake 'date(<format>)'
+var portableTypeRegex = regexp.MustCompile(`(?i)^(.*?)\[\s*(?:(int|string|bool|date|timestamptz|timestamp|json)|(decimal)\s*(?:\(([^)]*)\))?)\s*(\|[^\]]*)?\]\s*$`)
+
+// PortableTypes maps the portable types of the annotated headers to the types of a dialect, e.g. 'decimal' to 'number(%s)' with the arguments of the decimal.
+// A fixture annotated with the portable types 'int', 'decimal(<precision>,<scale>)', 'string', 'bool', 'date', 'timestamp', 'timestamptz' and 'json' is read by every dialect
+type PortableTypes map[string]string
+
+// Resolve returns the header with its portable type replaced by the type of the dialect, keeping the constraints, e.g. 'amount[number(10,2)|min=0]' for 'amount[decimal(10,2)|min=0]'.
+// Headers with a dialect type are returned unchanged
+func (p PortableTypes) Resolve(header string, dialect string) (string, error) {
+	match := portableTypeRegex.FindStringSubmatch(header)
+	if match == nil {
+		return header, nil
+	}
+	name, portable, args, constraints := match[1], strings.ToLower(match[2]), match[4], match[5]
+	if portable == "" {
+		portable = "decimal"
+	}
+	typ, ok := p[portable]
+	if !ok {
+		return "", fmt.Errorf("portable type '%s' of column '%s' has no %s type, annotate the column with a %s type instead", portable, strings.TrimSpace(name), dialect, dialect)
+	}
+	if strings.Contains(typ, "%s") {
+		typ = fmt.Sprintf(typ, strings.TrimSpace(args))
+	}
+	return fmt.Sprintf("%s[%s%s]", name, typ, constraints), nil
+}
+
+// IsPortable reports whether the annotated header has a portable type, e.g. 'amount[decimal(10,2)]'
+func IsPortable(header string) bool {
+	return portableTypeRegex.MatchString(header)
+}
@@ -28,14 +28,30 @@ func (m *BigInt) GetName() string {
 	return m.fieldName
 }
 
+// GetType implements formatter.ICsvHeader
+func (m *BigInt) GetType() string {
+	return "bigint"
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (v *BigInt) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		val, err := strconv.ParseInt(formatter.StringValue(value), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("error converting value '%s' to integer", formatter.StringValue(value))
+		}
+		return strconv.FormatInt(val, 10), nil
+	}
+}
+
 // GetWriter implements formatter.ICsvHeader.
 func (v *BigInt) GetWriter() func(value interface{}) ([]byte, error) {
 	return func(value interface{}) ([]byte, error) {
-		val, err := strconv.ParseInt(value.(string), 10, 64)
+		val, err := v.GetCsvWriter()(value)
 		if err != nil {
-			return nil, fmt.Errorf("error converting value '%s' to integer", value.(string))
+			return nil, err
 		}
-		return []byte(fmt.Sprintf("%d::bigint as %s", val, v.fieldName)), nil
+		return []byte(fmt.Sprintf("%s::bigint as %s", val, v.fieldName)), nil
 	}
 }
 
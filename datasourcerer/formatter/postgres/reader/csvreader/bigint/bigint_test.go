@@ -93,3 +93,31 @@ func Test_Bigint(t *testing.T) {
 		})
 	}
 }
+
+func Test_BigInt_CsvWriter(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         string
+		input          string
+		expectedOutput string
+		expectedError  string
+	}{
+		{name: "Test_BigInt_CsvWriter_Normalized", header: "foo[bigint()]", input: "+0042", expectedOutput: "42"},
+		{name: "Test_BigInt_CsvWriter_InvalidValue", header: "foo[bigint()]", input: "4.2", expectedError: "error converting value '4.2' to integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := bigint.BigInt{}
+			err := header.ParseHeader(tt.header)
+			assert.Nil(t, err)
+			content, err := header.GetCsvWriter()(tt.input)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expectedOutput, content)
+			}
+		})
+	}
+}
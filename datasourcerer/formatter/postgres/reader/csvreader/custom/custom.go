@@ -0,0 +1,79 @@
+package custom
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
+
+var _ formatter.ICsvHeader = &Custom{}
+
+// Signature must be "<name>[<type>()]" (case insensitive), where <type> is a custom type of the config
+var customSignatureRegex = regexp.MustCompile(`(?i)^(\w+)\[(\w+)\(\s*\)\]$`)
+
+// valueEscaper escapes a value for a single quoted string literal of the sql template, doubling its single quotes
+var valueEscaper = strings.NewReplacer(`'`, `''`)
+
+// Custom is a column of a custom type of the config, signified with "[<type>()]". Its values must match the regex of the type and are rendered with its sql template
+type Custom struct {
+	fieldName string
+	name      string
+	config    formatter.TypeConfig
+	pattern   *regexp.Regexp
+}
+
+// New returns the column parser of the custom type with the name
+func New(name string, config formatter.TypeConfig) *Custom {
+	return &Custom{name: name, config: config}
+}
+
+// GetName implements formatter.ICsvHeader
+func (m *Custom) GetName() string {
+	return m.fieldName
+}
+
+// GetType implements formatter.ICsvHeader
+func (m *Custom) GetType() string {
+	return m.config.SqlType()
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (v *Custom) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		val := formatter.StringValue(value)
+		if !v.pattern.MatchString(val) {
+			return "", fmt.Errorf("value '%s' does not match the regex '%s' of type '%s'", val, v.config.Regex, v.name)
+		}
+		return val, nil
+	}
+}
+
+// GetWriter implements formatter.ICsvHeader.
+func (v *Custom) GetWriter() func(value interface{}) ([]byte, error) {
+	return func(value interface{}) ([]byte, error) {
+		val, err := v.GetCsvWriter()(value)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(fmt.Sprintf("%s as %s", strings.ReplaceAll(v.config.Sql, "{{value}}", valueEscaper.Replace(val)), v.fieldName)), nil
+	}
+}
+
+func (v *Custom) ParseHeader(signature string) error {
+	matches := customSignatureRegex.FindStringSubmatch(signature)
+	if matches == nil || !strings.EqualFold(matches[2], v.name) {
+		return fmt.Errorf("invalid signature '%s'. Signature should be of the form <name>[%s()]", signature, v.name)
+	}
+	if v.config.SqlType() == "" {
+		return fmt.Errorf("custom type '%s' has no sql type, set its 'type' or end its sql with a '::<type>' cast", v.name)
+	}
+	pattern, err := v.config.Pattern()
+	if err != nil {
+		return fmt.Errorf("custom type '%s' has an %w", v.name, err)
+	}
+	v.pattern = pattern
+	v.fieldName = strings.TrimSpace(matches[1])
+	return nil
+}
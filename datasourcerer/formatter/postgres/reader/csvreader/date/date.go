@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
 )
@@ -41,14 +40,30 @@ func (m *Date) GetName() string {
 	return m.fieldName
 }
 
+// GetType implements formatter.ICsvHeader
+func (m *Date) GetType() string {
+	return "date"
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (d *Date) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		t, err := formatter.ParseTime(value, d.format)
+		if err != nil {
+			return "", fmt.Errorf("not able to convert value '%s' to date using the '%s' format", formatter.StringValue(value), d.format)
+		}
+		return t.Format(defaultDateFormat), nil
+	}
+}
+
 // GetWriter implements formatter.ICsvHeader.
 func (d *Date) GetWriter() func(value interface{}) ([]byte, error) {
 	return func(value interface{}) ([]byte, error) {
-		t, err := time.Parse(d.format, value.(string))
+		date, err := d.GetCsvWriter()(value)
 		if err != nil {
-			return nil, fmt.Errorf("not able to convert value '%s' to date using the '%s' format", value.(string), d.format)
+			return nil, err
 		}
-		return []byte(fmt.Sprintf("'%s'::date as %s", t.Format(defaultDateFormat), d.fieldName)), nil
+		return []byte(fmt.Sprintf("'%s'::date as %s", date, d.fieldName)), nil
 	}
 }
 
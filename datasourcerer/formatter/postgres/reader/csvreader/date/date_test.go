@@ -113,3 +113,31 @@ func Test_Date(t *testing.T) {
 		})
 	}
 }
+
+func Test_Date_CsvWriter(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         string
+		input          string
+		expectedOutput string
+		expectedError  string
+	}{
+		{name: "Test_Date_CsvWriter_Reformatted", header: "foo[date(dd/MM/yyyy)]", input: "31/12/2000", expectedOutput: "2000-12-31"},
+		{name: "Test_Date_CsvWriter_InvalidValue", header: "foo[date()]", input: "31.12.2000", expectedError: "not able to convert value '31.12.2000' to date using the '2006-01-02' format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := date.Date{}
+			err := header.ParseHeader(tt.header)
+			assert.Nil(t, err)
+			content, err := header.GetCsvWriter()(tt.input)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expectedOutput, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
+
+var _ formatter.ICsvHeader = &Expr{}
+
+// Signature must contain "[expr" (case insensitive) at any position and ends with ")]"
+var exprSignatureRegex = regexp.MustCompile(`(?i)^(\w+)\[expr\((.*)\)\]$`)
+
+const (
+	PostgresExprSignaturePrefix = "[expr("
+)
+
+// Expr is signified with "[expr(<optional-type>)]". Its values are sql expressions, e.g. 'CURRENT_DATE - 1', inserted verbatim and cast to the type when it is set
+type Expr struct {
+	fieldName string
+	typ       string
+}
+
+// GetName implements formatter.ICsvHeader
+func (m *Expr) GetName() string {
+	return m.fieldName
+}
+
+// GetType implements formatter.ICsvHeader. It is empty when the expressions are not cast
+func (m *Expr) GetType() string {
+	return m.typ
+}
+
+// GetCsvWriter implements formatter.ICsvHeader. The expressions are not evaluated, so the csv value is the expression
+func (v *Expr) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		return formatter.StringValue(value), nil
+	}
+}
+
+// GetWriter implements formatter.ICsvHeader.
+func (v *Expr) GetWriter() func(value interface{}) ([]byte, error) {
+	return func(value interface{}) ([]byte, error) {
+		if v.typ == "" {
+			return []byte(fmt.Sprintf("%s as %s", formatter.StringValue(value), v.fieldName)), nil
+		}
+		return []byte(fmt.Sprintf("(%s)::%s as %s", formatter.StringValue(value), v.typ, v.fieldName)), nil
+	}
+}
+
+func (v *Expr) ParseHeader(signature string) error {
+	if !strings.HasSuffix(signature, "]") {
+		return fmt.Errorf("invalid signature '%s'. Signature should be of the form <name>[expr(<optional-type>)]", signature)
+	}
+
+	if count := strings.Count(signature, "(") - strings.Count(signature, ")"); count != 0 {
+		return fmt.Errorf("unbalanced parentheses in signature '%s'", signature)
+	}
+
+	matches := exprSignatureRegex.FindStringSubmatch(signature)
+	if matches == nil {
+		return fmt.Errorf("invalid signature '%s'. Signature should be of the form <name>[expr(<optional-type>)]", signature)
+	}
+
+	v.typ = strings.TrimSpace(matches[2])
+	v.fieldName = strings.TrimSpace(matches[1])
+	return nil
+}
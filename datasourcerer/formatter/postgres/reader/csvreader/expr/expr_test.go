@@ -0,0 +1,73 @@
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/postgres/reader/csvreader/expr"
+)
+
+func Test_Expr(t *testing.T) {
+	tests := []struct {
+		name                 string
+		header               string
+		input                string
+		expectedHeaderName   string
+		expectedType         string
+		expectedWriterOutput string
+		expectedError        string
+	}{
+		{
+			name:                 "Test_Expr_DefaultAnnotation",
+			header:               "due[expr()]",
+			input:                "current_date - 1",
+			expectedHeaderName:   "due",
+			expectedWriterOutput: "current_date - 1 as due",
+		},
+		{
+			name:                 "Test_Expr_Cast",
+			header:               "payload[expr(jsonb)]",
+			input:                "jsonb_build_object('a', 1)",
+			expectedHeaderName:   "payload",
+			expectedType:         "jsonb",
+			expectedWriterOutput: "(jsonb_build_object('a', 1))::jsonb as payload",
+		},
+		{
+			name:                 "Test_Expr_CastWithArguments",
+			header:               "amount[EXPR(numeric(10,2))]",
+			input:                "1 + 2",
+			expectedHeaderName:   "amount",
+			expectedType:         "numeric(10,2)",
+			expectedWriterOutput: "(1 + 2)::numeric(10,2) as amount",
+		},
+		{
+			name:          "Test_Expr_Exception_ExtraOpeningParenthesis",
+			header:        "foo[expr(()]",
+			expectedError: "unbalanced parentheses in signature 'foo[expr(()]'",
+		},
+		{
+			name:          "Test_Expr_Exception_ExtraContentOutsideParenthesis",
+			header:        "foo[expr()]ExtraContent",
+			expectedError: "invalid signature 'foo[expr()]ExtraContent'. Signature should be of the form <name>[expr(<optional-type>)]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := expr.Expr{}
+			err := header.ParseHeader(tt.header)
+
+			if tt.expectedError != "" {
+				assert.NotNil(t, err)
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expectedHeaderName, header.GetName())
+				assert.Equal(t, tt.expectedType, header.GetType())
+				content, err := header.GetWriter()(tt.input)
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expectedWriterOutput, string(content))
+			}
+		})
+	}
+}
@@ -28,17 +28,33 @@ func (m *Integer) GetName() string {
 	return m.fieldName
 }
 
+// GetType implements formatter.ICsvHeader
+func (m *Integer) GetType() string {
+	return "int"
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (v *Integer) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		val, err := strconv.ParseInt(formatter.StringValue(value), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("error converting value '%s' to integer", formatter.StringValue(value))
+		}
+		if val < -2147483648 || val > 2147483647 {
+			return "", fmt.Errorf("value %d is out of range for integer, must be in range -2.147.483.648 to 2.147.483.647", val)
+		}
+		return strconv.FormatInt(val, 10), nil
+	}
+}
+
 // GetWriter implements formatter.ICsvHeader.
 func (v *Integer) GetWriter() func(value interface{}) ([]byte, error) {
 	return func(value interface{}) ([]byte, error) {
-		val, err := strconv.ParseInt(value.(string), 10, 64)
+		val, err := v.GetCsvWriter()(value)
 		if err != nil {
-			return nil, fmt.Errorf("error converting value '%s' to integer", value.(string))
-		}
-		if val < -2147483648 || val > 2147483647 {
-			return nil, fmt.Errorf("value %d is out of range for integer, must be in range -2.147.483.648 to 2.147.483.647", val)
+			return nil, err
 		}
-		return []byte(fmt.Sprintf("%d::int as %s", val, v.fieldName)), nil
+		return []byte(fmt.Sprintf("%s::int as %s", val, v.fieldName)), nil
 	}
 }
 
@@ -27,10 +27,22 @@ func (m *Jsonb) GetName() string {
 	return m.fieldName
 }
 
+// GetType implements formatter.ICsvHeader
+func (m *Jsonb) GetType() string {
+	return "jsonb"
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (v *Jsonb) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		return formatter.StringValue(value), nil
+	}
+}
+
 // GetWriter implements formatter.ICsvHeader.
 func (v *Jsonb) GetWriter() func(value interface{}) ([]byte, error) {
 	return func(value interface{}) ([]byte, error) {
-		return []byte(fmt.Sprintf("'%s'::jsonb as %s", value, v.fieldName)), nil
+		return []byte(fmt.Sprintf("'%s'::jsonb as %s", formatter.StringValue(value), v.fieldName)), nil
 	}
 }
 
@@ -29,30 +29,51 @@ func (m *Numeric) GetName() string {
 	return m.fieldName
 }
 
+// GetType implements formatter.ICsvHeader
+func (m *Numeric) GetType() string {
+	if m.precision == -99999 && m.scale == -99999 {
+		return "numeric"
+	}
+	return fmt.Sprintf("numeric(%d,%d)", m.precision, m.scale)
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (n *Numeric) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		_, err := strconv.ParseFloat(formatter.StringValue(value), 64)
+		if err != nil {
+			return "", fmt.Errorf("error converting value '%s' to float", formatter.StringValue(value))
+		}
+		if n.precision == -99999 && n.scale == -99999 {
+			return formatter.StringValue(value), nil
+		}
+		if n.precision == -99999 {
+			return "", fmt.Errorf("precision must be spesified along with scale")
+		}
+		if n.scale == -99999 {
+			return "", fmt.Errorf("scale must be spesified along with precision")
+		}
+		if n.precision > 1000 || n.precision < 0 {
+			return "", fmt.Errorf("invalid precision value: '%d', must be in range 0-1000", n.precision)
+		}
+		if n.scale > 999 || n.scale < 0 || n.scale > n.precision {
+			return "", fmt.Errorf("invalid scale value: '%d', must be smaller than precision value '%d'", n.precision, n.scale)
+		}
+		return formatter.StringValue(value), nil
+	}
+}
+
 // GetWriter implements formatter.ICsvHeader.
 func (n *Numeric) GetWriter() func(value interface{}) ([]byte, error) {
 	return func(value interface{}) ([]byte, error) {
-		_, err := strconv.ParseFloat(value.(string), 64)
+		val, err := n.GetCsvWriter()(value)
 		if err != nil {
-			return nil, fmt.Errorf("error converting value '%s' to float", value.(string))
+			return nil, err
 		}
 		if n.precision == -99999 && n.scale == -99999 {
-			return []byte(fmt.Sprintf("%s::numeric as %s", value.(string), n.fieldName)), nil
-		} else {
-			if n.precision == -99999 {
-				return nil, fmt.Errorf("precision must be spesified along with scale")
-			}
-			if n.scale == -99999 {
-				return nil, fmt.Errorf("scale must be spesified along with precision")
-			}
-			if n.precision > 1000 || n.precision < 0 {
-				return nil, fmt.Errorf("invalid precision value: '%d', must be in range 0-1000", n.precision)
-			}
-			if n.scale > 999 || n.scale < 0 || n.scale > n.precision {
-				return nil, fmt.Errorf("invalid scale value: '%d', must be smaller than precision value '%d'", n.precision, n.scale)
-			}
-			return []byte(fmt.Sprintf("%s::numeric(%d,%d) as %s", value.(string), n.precision, n.scale, n.fieldName)), nil
+			return []byte(fmt.Sprintf("%s::numeric as %s", val, n.fieldName)), nil
 		}
+		return []byte(fmt.Sprintf("%s::numeric(%d,%d) as %s", val, n.precision, n.scale, n.fieldName)), nil
 	}
 }
 
@@ -28,17 +28,33 @@ func (m *SmallInt) GetName() string {
 	return m.fieldName
 }
 
+// GetType implements formatter.ICsvHeader
+func (m *SmallInt) GetType() string {
+	return "smallint"
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (v *SmallInt) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		val, err := strconv.ParseInt(formatter.StringValue(value), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("error converting value '%s' to integer", formatter.StringValue(value))
+		}
+		if val < -32768 || val > 32768 {
+			return "", fmt.Errorf("value %d is out of range for integer, must be in range -32.768 to 32.768", val)
+		}
+		return strconv.FormatInt(val, 10), nil
+	}
+}
+
 // GetWriter implements formatter.ICsvHeader.
 func (v *SmallInt) GetWriter() func(value interface{}) ([]byte, error) {
 	return func(value interface{}) ([]byte, error) {
-		val, err := strconv.ParseInt(value.(string), 10, 64)
+		val, err := v.GetCsvWriter()(value)
 		if err != nil {
-			return nil, fmt.Errorf("error converting value '%s' to integer", value.(string))
-		}
-		if val < -32768 || val > 32768 {
-			return nil, fmt.Errorf("value %d is out of range for integer, must be in range -32.768 to 32.768", val)
+			return nil, err
 		}
-		return []byte(fmt.Sprintf("%d::smallint as %s", val, v.fieldName)), nil
+		return []byte(fmt.Sprintf("%s::smallint as %s", val, v.fieldName)), nil
 	}
 }
 
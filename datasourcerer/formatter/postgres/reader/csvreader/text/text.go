@@ -27,10 +27,22 @@ func (m *Text) GetName() string {
 	return m.fieldName
 }
 
+// GetType implements formatter.ICsvHeader
+func (m *Text) GetType() string {
+	return "text"
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (v *Text) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		return formatter.StringValue(value), nil
+	}
+}
+
 // GetWriter implements formatter.ICsvHeader.
 func (v *Text) GetWriter() func(value interface{}) ([]byte, error) {
 	return func(value interface{}) ([]byte, error) {
-		return []byte(fmt.Sprintf("'%s'::text as %s", value, v.fieldName)), nil
+		return []byte(fmt.Sprintf("'%s'::text as %s", formatter.StringValue(value), v.fieldName)), nil
 	}
 }
 
@@ -5,7 +5,6 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/postgres/reader/csvreader/time/utils"
@@ -33,19 +32,34 @@ func (t *TimeTz) GetName() string {
 	return t.fieldName
 }
 
-// GetWriter implements formatter.ICsvHeader.
-func (t *TimeTz) GetWriter() func(value interface{}) ([]byte, error) {
-	return func(value interface{}) ([]byte, error) {
+// GetType implements formatter.ICsvHeader
+func (t *TimeTz) GetType() string {
+	return t.timeSignature
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (t *TimeTz) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
 		// Parse the time based on the specified format
-		parsedTime, err := time.Parse(t.format, value.(string))
+		parsed, err := formatter.ParseTime(value, t.format)
 		if err != nil {
-			return nil, fmt.Errorf("not able to convert value '%s' to time using the '%s' format", value.(string), t.format)
+			return "", fmt.Errorf("not able to convert value '%s' to time using the '%s' format", formatter.StringValue(value), t.format)
 		}
 
 		// Convert the time to a string in the default time format
-		timeString := parsedTime.Format(defaultTimeFormat)
+		return parsed.Format(defaultTimeFormat), nil
+	}
+}
+
+// GetWriter implements formatter.ICsvHeader.
+func (t *TimeTz) GetWriter() func(value interface{}) ([]byte, error) {
+	return func(value interface{}) ([]byte, error) {
+		timeString, err := t.GetCsvWriter()(value)
+		if err != nil {
+			return nil, err
+		}
 
-		// Return the formatted time string with the appropriate Snowflake type and alias
+		// Return the formatted time string with the appropriate Postgres type and alias
 		return []byte(fmt.Sprintf("'%s'::%s as %s", timeString, t.timeSignature, t.fieldName)), nil
 	}
 }
@@ -5,3 +5,17 @@ import "io"
 type IReader interface {
 	Read(r io.Reader) ([]byte, error)
 }
+
+// ITableReader is a reader that keeps the table it read, e.g. a csv reader
+type ITableReader interface {
+	IReader
+	Table() *Table
+}
+
+// TableOf returns the table kept by the reader, or nil when the reader does not keep one, e.g. for sql files
+func TableOf(reader IReader) *Table {
+	if tableReader, ok := reader.(ITableReader); ok {
+		return tableReader.Table()
+	}
+	return nil
+}
@@ -27,7 +27,7 @@ func Constructor() func(*slog.Logger, *formatter.Config) *SnowflakeFormatter {
 		case formatter.ParserInputTypeSql:
 			reader = sqlreader.NewSqlReader(logger)
 		case formatter.ParserInputTypeCsv:
-			reader = csvreader.NewCsvReader(logger, config.CSV)
+			reader = csvreader.NewCsvReader(logger, config.CSV).WithTypes(config.Types)
 		default:
 			panic(fmt.Sprintf("invalid input type: '%s'", config.Filetype))
 		}
@@ -51,3 +51,8 @@ func (s *SnowflakeFormatter) Read(r io.Reader) error {
 func (s *SnowflakeFormatter) Write(writer io.Writer) error {
 	return s.writer.Write(writer, s.content)
 }
+
+// Table implements formatter.IDataSourceFormatter.
+func (s *SnowflakeFormatter) Table() *formatter.Table {
+	return formatter.TableOf(s.reader)
+}
@@ -32,16 +32,29 @@ func (m *Boolean) GetName() string {
 	return m.fieldName
 }
 
+// GetType implements formatter.ICsvHeader
+func (m *Boolean) GetType() string {
+	return "BOOLEAN"
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (b *Boolean) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		if b.trueRepresentation == value {
+			return fmt.Sprint(true), nil
+		} else if b.falseRepresentation == value {
+			return fmt.Sprint(false), nil
+		}
+		return "", fmt.Errorf("invalid boolean value '%s', expected '%s' (true) or '%s' (false)", value, b.trueRepresentation, b.falseRepresentation)
+	}
+}
+
 // GetWriter implements formatter.ICsvHeader.
 func (b *Boolean) GetWriter() func(value interface{}) ([]byte, error) {
 	return func(value interface{}) ([]byte, error) {
-		var val string
-		if b.trueRepresentation == value {
-			val = fmt.Sprint(true)
-		} else if b.falseRepresentation == value {
-			val = fmt.Sprint(false)
-		} else {
-			return nil, fmt.Errorf("invalid boolean value '%s', expected '%s' (true) or '%s' (false)", value, b.trueRepresentation, b.falseRepresentation)
+		val, err := b.GetCsvWriter()(value)
+		if err != nil {
+			return nil, err
 		}
 		return []byte(fmt.Sprintf("%s::BOOLEAN AS %s", val, strings.ToUpper(b.fieldName))), nil
 	}
@@ -142,3 +142,32 @@ func Test_Boolean(t *testing.T) {
 		})
 	}
 }
+
+func Test_Boolean_CsvWriter(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         string
+		input          string
+		expectedOutput string
+		expectedError  string
+	}{
+		{name: "Test_Boolean_CsvWriter_True", header: "foo[boolean(T,F)]", input: "T", expectedOutput: "true"},
+		{name: "Test_Boolean_CsvWriter_False", header: "foo[boolean(T,F)]", input: "F", expectedOutput: "false"},
+		{name: "Test_Boolean_CsvWriter_InvalidValue", header: "foo[boolean(T,F)]", input: "X", expectedError: "invalid boolean value 'X', expected 'T' (true) or 'F' (false)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := boolean.Boolean{}
+			err := header.ParseHeader(tt.header)
+			assert.Nil(t, err)
+			content, err := header.GetCsvWriter()(tt.input)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expectedOutput, content)
+			}
+		})
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
 )
@@ -24,7 +23,8 @@ type Date struct {
 	format    string
 }
 
-var dateFormatMapper = map[string]string{
+// DateFormatMapper maps the formats of a date annotation, e.g. 'dd/MM/yyyy', to go layouts. Other formats are used as go layouts
+var DateFormatMapper = map[string]string{
 	"yyyy-MM-dd":           "2006-01-02",                // Example: "2023-10-24"
 	"dd-MM-yyyy":           "02-01-2006",                // Example: "24-10-2023"
 	"MM/dd/yyyy":           "01/02/2006",                // Example: "10/24/2023"
@@ -41,14 +41,30 @@ func (m *Date) GetName() string {
 	return m.fieldName
 }
 
+// GetType implements formatter.ICsvHeader
+func (m *Date) GetType() string {
+	return "DATE"
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (d *Date) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		t, err := formatter.ParseTime(value, d.format)
+		if err != nil {
+			return "", fmt.Errorf("not able to convert value '%s' to date using the '%s' format", formatter.StringValue(value), d.format)
+		}
+		return t.Format(defaultDateFormat), nil
+	}
+}
+
 // GetWriter implements formatter.ICsvHeader.
 func (d *Date) GetWriter() func(value interface{}) ([]byte, error) {
 	return func(value interface{}) ([]byte, error) {
-		t, err := time.Parse(d.format, value.(string))
+		date, err := d.GetCsvWriter()(value)
 		if err != nil {
-			return nil, fmt.Errorf("not able to convert value '%s' to date using the '%s' format", value.(string), d.format)
+			return nil, err
 		}
-		return []byte(fmt.Sprintf("'%s'::DATE AS %s", t.Format(defaultDateFormat), strings.ToUpper(d.fieldName))), nil
+		return []byte(fmt.Sprintf("'%s'::DATE AS %s", date, strings.ToUpper(d.fieldName))), nil
 	}
 }
 
@@ -80,7 +96,7 @@ func (d *Date) ParseHeader(signature string) error {
 
 	// Parse optional format
 	if len(params) > 0 && strings.TrimSpace(params[0]) != "" {
-		if format, ok := dateFormatMapper[strings.TrimSpace(params[0])]; ok {
+		if format, ok := DateFormatMapper[strings.TrimSpace(params[0])]; ok {
 			d.format = format
 		} else {
 			d.format = strings.TrimSpace(params[0])
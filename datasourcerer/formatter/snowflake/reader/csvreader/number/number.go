@@ -31,21 +31,37 @@ func (m *Number) GetName() string {
 	return m.fieldName
 }
 
-// GetWriter implements formatter.ICsvHeader.
-func (n *Number) GetWriter() func(value interface{}) ([]byte, error) {
-	return func(value interface{}) ([]byte, error) {
+// GetType implements formatter.ICsvHeader
+func (m *Number) GetType() string {
+	return fmt.Sprintf("NUMBER(%d,%d)", m.precision, m.scale)
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (n *Number) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
 		if n.scale == 0 {
-			_, err := strconv.ParseInt(value.(string), 10, 64)
+			_, err := strconv.ParseInt(formatter.StringValue(value), 10, 64)
 			if err != nil {
-				return nil, fmt.Errorf("error converting value '%s' to integer", value.(string))
+				return "", fmt.Errorf("error converting value '%s' to integer", formatter.StringValue(value))
 			}
 		} else {
-			_, err := strconv.ParseFloat(value.(string), 64)
+			_, err := strconv.ParseFloat(formatter.StringValue(value), 64)
 			if err != nil {
-				return nil, fmt.Errorf("error converting value '%s' to float", value.(string))
+				return "", fmt.Errorf("error converting value '%s' to float", formatter.StringValue(value))
 			}
 		}
-		return []byte(fmt.Sprintf("%v::NUMBER(%d,%d) AS %s", value, n.precision, n.scale, strings.ToUpper(n.fieldName))), nil
+		return formatter.StringValue(value), nil
+	}
+}
+
+// GetWriter implements formatter.ICsvHeader.
+func (n *Number) GetWriter() func(value interface{}) ([]byte, error) {
+	return func(value interface{}) ([]byte, error) {
+		val, err := n.GetCsvWriter()(value)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(fmt.Sprintf("%v::NUMBER(%d,%d) AS %s", val, n.precision, n.scale, strings.ToUpper(n.fieldName))), nil
 	}
 }
 
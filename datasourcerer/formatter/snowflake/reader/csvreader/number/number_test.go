@@ -140,3 +140,32 @@ func Test_Number(t *testing.T) {
 		})
 	}
 }
+
+func Test_Number_CsvWriter(t *testing.T) {
+	tests := []struct {
+		name           string
+		header         string
+		input          string
+		expectedOutput string
+		expectedError  string
+	}{
+		{name: "Test_Number_CsvWriter_Integer", header: "foo[number(10,0)]", input: "42", expectedOutput: "42"},
+		{name: "Test_Number_CsvWriter_Float", header: "foo[number(10,2)]", input: "42.10", expectedOutput: "42.10"},
+		{name: "Test_Number_CsvWriter_InvalidValue", header: "foo[number(10,0)]", input: "42.1", expectedError: "error converting value '42.1' to integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := number.Number{}
+			err := header.ParseHeader(tt.header)
+			assert.Nil(t, err)
+			content, err := header.GetCsvWriter()(tt.input)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expectedOutput, content)
+			}
+		})
+	}
+}
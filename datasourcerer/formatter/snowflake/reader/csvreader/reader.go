@@ -1,16 +1,21 @@
 package csvreader
 
 import (
-	"bytes"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/tsanton/dbt-unit-test-fusionizer/expression"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/boolean"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/custom"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/date"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/expr"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/number"
 	stime "github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/time"
 	dtd "github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/timestamp/datetime"
@@ -18,12 +23,16 @@ import (
 	dtntz "github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/timestamp/ntz"
 	dttz "github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/timestamp/tz"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/varchar"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/variant"
 )
 
-var parserTypes = []struct {
+// parserType creates the parser of the headers whose lowercase annotation contains the prefix, e.g. '[varchar('
+type parserType struct {
 	prefix string
 	create func() formatter.ICsvHeader
-}{
+}
+
+var parserTypes = []parserType{
 	// Add other parsers as needed
 	{prefix: varchar.SnowflakeVarcharSignaturePrefix, create: func() formatter.ICsvHeader { return &varchar.Varchar{} }},
 	{prefix: boolean.SnowflakeBooleanSignaturePrefix, create: func() formatter.ICsvHeader { return &boolean.Boolean{} }},
@@ -34,20 +43,59 @@ var parserTypes = []struct {
 	{prefix: dtntz.SnowflakeTimestampNoTimeZoneSignaturePrefix, create: func() formatter.ICsvHeader { return &dtntz.TimestampNtz{} }},
 	{prefix: dtltz.SnowflakeTimestampLocalTimeZoneSignaturePrefix, create: func() formatter.ICsvHeader { return &dtltz.TimestampLtz{} }},
 	{prefix: dttz.SnowflakeTimestampTimeZoneSignaturePrefix, create: func() formatter.ICsvHeader { return &dttz.TimestampTz{} }},
+	{prefix: expr.SnowflakeExprSignaturePrefix, create: func() formatter.ICsvHeader { return &expr.Expr{} }},
+	{prefix: variant.SnowflakeVariantSignaturePrefix, create: func() formatter.ICsvHeader { return &variant.Variant{} }},
+}
+
+// portableTypes maps the portable types of the headers to the snowflake types
+var portableTypes = formatter.PortableTypes{
+	"int":         "number(38,0)",
+	"decimal":     "number(%s)",
+	"string":      "varchar()",
+	"bool":        "boolean()",
+	"date":        "date()",
+	"timestamp":   "timestamp_ntz()",
+	"timestamptz": "timestamp_tz()",
+	"json":        "variant()",
 }
 
-var _ formatter.IReader = &CsvlReader{}
+var _ formatter.ITableReader = &CsvlReader{}
 
 type CsvlReader struct {
-	logger *slog.Logger
-	config formatter.CsvConfig
+	logger  *slog.Logger
+	config  formatter.CsvConfig
+	table   *formatter.Table
+	types   formatter.Types
+	parsers []parserType
 }
 
 func NewCsvReader(logger *slog.Logger, config formatter.CsvConfig) *CsvlReader {
 	return &CsvlReader{
-		logger: logger,
-		config: config,
+		logger:  logger,
+		config:  config,
+		parsers: parserTypes,
+	}
+}
+
+// WithTypes registers the alias and custom types of the config alongside the built-in types. Types named like a built-in or portable type are ignored
+func (r *CsvlReader) WithTypes(types formatter.Types) *CsvlReader {
+	r.types = formatter.Types{}
+	r.parsers = append([]parserType{}, parserTypes...)
+	for name, config := range types {
+		prefix := "[" + strings.ToLower(name) + "("
+		_, portable := portableTypes[strings.ToLower(name)]
+		builtin := slices.ContainsFunc(parserTypes, func(p parserType) bool { return p.prefix == prefix })
+		if portable || builtin {
+			r.logger.Warn(fmt.Sprintf("type '%s' of the config is ignored, it is a built-in snowflake type", name))
+			continue
+		}
+		r.types[name] = config
+		if config.Alias == "" {
+			name, config := name, config
+			r.parsers = append(r.parsers, parserType{prefix: prefix, create: func() formatter.ICsvHeader { return custom.New(name, config) }})
+		}
 	}
+	return r
 }
 
 func (r *CsvlReader) Read(reader io.Reader) ([]byte, error) {
@@ -66,42 +114,70 @@ func (r *CsvlReader) Read(reader io.Reader) ([]byte, error) {
 	}
 
 	headers, err := r.parseCsvHeaders(raw)
+	if err != nil {
+		var parseErr *formatter.ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.Line, parseErr.Column = cr.FieldPos(parseErr.ColumnIndex - 1)
+		}
+		return nil, err
+	}
+	reference, err := expression.ParseReference(r.config.Expressions.ReferenceDate)
+	if err != nil {
+		return nil, err
+	}
+	table, err := r.parseCsvTable(cr, headers, expression.NewEvaluator(reference, r.config.Expressions.Seed).WithSource(r.config.Expressions.Source))
 	if err != nil {
 		return nil, err
 	}
-	return r.parseCsvContent(cr, headers)
+	r.table = table
+	return table.Sql(), nil
+}
 
+// Table implements formatter.ITableReader.
+func (r *CsvlReader) Table() *formatter.Table {
+	return r.table
 }
 
 func (f *CsvlReader) parseCsvHeaders(headers []string) (map[int]formatter.ICsvHeader, error) {
 	formatters := map[int]formatter.ICsvHeader{}
 	for idx, header := range headers {
-		col := strings.TrimSpace(strings.ToLower(header))
+		resolved, err := portableTypes.Resolve(f.types.Resolve(header), "snowflake")
+		if err != nil {
+			return nil, &formatter.ParseError{ColumnIndex: idx + 1, Value: header, Err: err}
+		}
+		typed, constraints := formatter.SplitConstraints(resolved)
+		col := strings.TrimSpace(strings.ToLower(typed))
 		if !strings.Contains(col, `[`) && !strings.HasSuffix(col, `)]`) {
-			formatter := &varchar.Varchar{}
-			if err := formatter.ParseHeader(header); err != nil {
-				return nil, err
+			parser := &varchar.Varchar{}
+			if err := parser.ParseHeader(header); err != nil {
+				return nil, &formatter.ParseError{ColumnIndex: idx + 1, Value: header, Err: err}
 			}
-			formatters[idx] = formatter
+			formatters[idx] = parser
 			continue
 		}
 
 		parsed := false
-		for _, parserType := range parserTypes {
+		for _, parserType := range f.parsers {
 			if strings.Contains(col, parserType.prefix) && strings.HasSuffix(col, `)]`) {
-				formatter := parserType.create()
-				if err := formatter.ParseHeader(header); err != nil {
-					return nil, err
+				parser := parserType.create()
+				if err := parser.ParseHeader(typed); err != nil {
+					return nil, &formatter.ParseError{ColumnIndex: idx + 1, Value: header, Err: err}
+				}
+				formatters[idx] = parser
+				if len(constraints) > 0 {
+					constrained, err := formatter.NewConstrainedHeader(parser, constraints)
+					if err != nil {
+						return nil, &formatter.ParseError{ColumnIndex: idx + 1, Value: header, Err: err}
+					}
+					formatters[idx] = constrained
 				}
-				formatters[idx] = formatter
 				parsed = true
 				break
 			}
 		}
 
 		if !parsed {
-			//TODO: Log error
-			return nil, fmt.Errorf("unable to parse header `%s`", header)
+			return nil, &formatter.ParseError{ColumnIndex: idx + 1, Value: header, Err: fmt.Errorf("unable to parse header `%s`", header)}
 		}
 	}
 
@@ -109,8 +185,35 @@ func (f *CsvlReader) parseCsvHeaders(headers []string) (map[int]formatter.ICsvHe
 }
 
 func (f *CsvlReader) parseCsvContent(r *csv.Reader, parsers map[int]formatter.ICsvHeader) ([]byte, error) {
-	var buffer bytes.Buffer
-	firstRecord := true
+	table, err := f.parseCsvTable(r, parsers, expression.NewEvaluator(time.Now().UTC(), 0))
+	if err != nil {
+		return nil, err
+	}
+	return table.Sql(), nil
+}
+
+// evaluate returns the value of a cell of the column (0-based) with its expression evaluated. The values of text and custom type columns are kept as they are
+// when the config keeps text, so that a text starting with '=' is not rewritten. The cells of sql expression columns are never evaluated
+func (f *CsvlReader) evaluate(evaluator *expression.Evaluator, header formatter.ICsvHeader, column int, value string) (interface{}, error) {
+	switch formatter.UnwrapHeader(header).(type) {
+	case *expr.Expr:
+		return value, nil
+	case *varchar.Varchar, *custom.Custom:
+		if f.config.Expressions.KeepText {
+			return value, nil
+		}
+	}
+	return evaluator.Evaluate(column, value)
+}
+
+// parseCsvTable parses the records of the data source, rendering every value as sql and as normalized csv.
+// Cell expressions such as '=today-3d' are evaluated before the value is validated by the column parser, see evaluate
+func (f *CsvlReader) parseCsvTable(r *csv.Reader, parsers map[int]formatter.ICsvHeader, evaluator *expression.Evaluator) (*formatter.Table, error) {
+	table := &formatter.Table{Columns: make([]formatter.Column, len(parsers))}
+	for i := range table.Columns {
+		_, isExpr := parsers[i].(*expr.Expr)
+		table.Columns[i] = formatter.Column{Name: parsers[i].GetName(), Type: parsers[i].GetType(), Expr: isExpr}
+	}
 	for {
 		record, err := r.Read()
 		if err != nil {
@@ -119,40 +222,79 @@ func (f *CsvlReader) parseCsvContent(r *csv.Reader, parsers map[int]formatter.IC
 			}
 			return nil, err
 		}
-
-		if firstRecord {
-			firstRecord = false
-		} else {
-			if _, err := buffer.WriteString("UNION ALL\n"); err != nil {
-				return nil, err
+		if len(record) > len(parsers) {
+			line, column := r.FieldPos(len(parsers))
+			return nil, &formatter.ParseError{
+				Line:        line,
+				Column:      column,
+				ColumnIndex: len(parsers) + 1,
+				Value:       record[len(parsers)],
+				Err:         fmt.Errorf("line %d has %d values, but the header defines %d columns", line, len(record), len(parsers)),
 			}
 		}
-		if _, err := buffer.WriteString("SELECT "); err != nil {
-			return nil, err
-		}
 
+		line, _ := r.FieldPos(0)
+		row := formatter.Row{Line: line, Cells: make([]formatter.Cell, len(record))}
 		for i, value := range record {
-			parsedValue, err := parsers[i].GetWriter()(value)
+			constrained, _ := parsers[i].(*formatter.ConstrainedHeader)
+			if constrained != nil {
+				value = constrained.Default(value)
+			}
+			if _, isText := formatter.UnwrapHeader(parsers[i]).(*varchar.Varchar); value == formatter.NullValue || value == "" && !isText {
+				// '\N' is NULL in any column. An empty value of a typed column is NULL, while it is an empty string in a text column
+				if constrained != nil {
+					if err := constrained.CheckNull(line); err != nil {
+						_, column := r.FieldPos(i)
+						return nil, &formatter.ParseError{Line: line, Column: column, ColumnIndex: i + 1, ColumnName: parsers[i].GetName(), Value: value, Err: err}
+					}
+				}
+				row.Cells[i].Null = true
+				if parsers[i].GetType() == "" {
+					// A sql expression column without a cast has no type
+					row.Cells[i].Sql = []byte(fmt.Sprintf("NULL AS %s", parsers[i].GetName()))
+				} else {
+					row.Cells[i].Sql = []byte(fmt.Sprintf("NULL::%s AS %s", parsers[i].GetType(), parsers[i].GetName()))
+				}
+				continue
+			}
+			cell, err := f.evaluate(evaluator, parsers[i], i, value)
 			if err != nil {
-				err := fmt.Errorf("error parsing value '%s' for column '%s' in line %d", value, parsers[i].GetName(), i+1)
-				f.logger.Error(err.Error())
-				buffer.Reset()
-				buffer.WriteString(err.Error())
-				return nil, err
+				line, column := r.FieldPos(i)
+				return nil, &formatter.ParseError{
+					Line:        line,
+					Column:      column,
+					ColumnIndex: i + 1,
+					ColumnName:  parsers[i].GetName(),
+					Value:       value,
+					Err:         fmt.Errorf("error evaluating expression '%s' for column '%s' in line %d: %w", value, parsers[i].GetName(), line, err),
+				}
+			}
+			sql, err := parsers[i].GetWriter()(cell)
+			if err == nil {
+				row.Cells[i].Value, err = parsers[i].GetCsvWriter()(cell)
 			}
-			if _, err := buffer.Write(parsedValue); err != nil {
-				return nil, err
+			if err != nil {
+				line, column := r.FieldPos(i)
+				err := fmt.Errorf("error parsing value '%s' for column '%s' in line %d", value, parsers[i].GetName(), line)
+				f.logger.Error(err.Error())
+				return nil, &formatter.ParseError{
+					Line:        line,
+					Column:      column,
+					ColumnIndex: i + 1,
+					ColumnName:  parsers[i].GetName(),
+					Value:       value,
+					Err:         err,
+				}
 			}
-			if _, err := buffer.WriteString(", "); err != nil {
-				return nil, err
+			row.Cells[i].Sql = sql
+			if constrained != nil {
+				if err := constrained.Check(row.Cells[i].Value, line); err != nil {
+					_, column := r.FieldPos(i)
+					return nil, &formatter.ParseError{Line: line, Column: column, ColumnIndex: i + 1, ColumnName: parsers[i].GetName(), Value: value, Err: err}
+				}
 			}
 		}
-		if buffer.Len() > 0 {
-			buffer.Truncate(buffer.Len() - 2) // remove the trailing comma
-		}
-		if err := buffer.WriteByte('\n'); err != nil {
-			return nil, err
-		}
+		table.Rows = append(table.Rows, row)
 	}
-	return bytes.TrimRight(buffer.Bytes(), "\n"), nil
+	return table, nil
 }
@@ -0,0 +1,104 @@
+package csvreader_test
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/number"
+)
+
+func Test_Constraints_ParseCsvHeaders(t *testing.T) {
+	t.Parallel()
+	headers, err := csvreader.ParseCsvHeaders(reader, []string{"Id[number(38,0)|not_null|unique]", "Amount[number(10,2)]"})
+	assert.Nil(t, err)
+
+	constrained, ok := headers[0].(*formatter.ConstrainedHeader)
+	assert.True(t, ok)
+	_, ok = formatter.UnwrapHeader(constrained).(*number.Number)
+	assert.True(t, ok)
+	assert.Equal(t, "ID", constrained.GetName())
+	assert.Equal(t, "NUMBER(38,0)", constrained.GetType())
+
+	_, ok = headers[1].(*number.Number)
+	assert.True(t, ok)
+}
+
+func Test_Constraints_ReadCsv(t *testing.T) {
+	t.Parallel()
+	data := strings.TrimSpace(`
+"Id[number(38,0)|not_null|unique]","Status[varchar(10)|default=open|enum=open,closed]","Amount[number(10,2)|min=0|max=100]"
+1,,0
+2,closed,99.5
+`)
+	r := csv.NewReader(strings.NewReader(data))
+	row, err := r.Read()
+	assert.Nil(t, err)
+
+	headers, err := csvreader.ParseCsvHeaders(reader, row)
+	assert.Nil(t, err)
+
+	content, err := csvreader.ParseCsvContent(reader, r, headers)
+	assert.Nil(t, err)
+
+	expected := strings.TrimSpace(`
+SELECT 1::NUMBER(38,0) AS ID, 'open'::VARCHAR(10) AS STATUS, 0::NUMBER(10,2) AS AMOUNT
+UNION ALL
+SELECT 2::NUMBER(38,0) AS ID, 'closed'::VARCHAR(10) AS STATUS, 99.5::NUMBER(10,2) AS AMOUNT
+`)
+	assert.Equal(t, expected, string(content))
+}
+
+func Test_Constraints_Violations(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		values string
+		err    string
+		line   int
+	}{
+		{name: "not_null", header: "\"Id[number(38,0)|not_null]\",Name", values: "1,John\n,Jane", err: "value of column 'ID' in line 3 is NULL, but the column is not_null", line: 3},
+		{name: "unique", header: "\"Id[number(38,0)|unique]\"", values: "1\n2\n1", err: "value '1' of column 'ID' in line 4 is not unique, it is also in line 2", line: 4},
+		{name: "unique allows NULL", header: "\"Id[number(38,0)|unique]\",Name", values: "1,John\n,Jane\n,Joe"},
+		{name: "enum", header: "\"Status[varchar(10)|enum=open,closed]\"", values: "open\npending", err: "value 'pending' of column 'STATUS' in line 3 is not one of the enum values open, closed", line: 3},
+		{name: "min", header: "\"Amount[number(10,2)|min=0]\"", values: "1.5\n-0.01", err: "value '-0.01' of column 'AMOUNT' in line 3 is less than the min 0", line: 3},
+		{name: "max date", header: "Due[date()|max=2024-12-31]", values: "2025-01-01", err: "value '2025-01-01' of column 'DUE' in line 2 is greater than the max 2024-12-31", line: 2},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := reader.Read(strings.NewReader(tt.header + "\n" + tt.values + "\n"))
+			if tt.err == "" {
+				assert.Nil(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+			assert.Equal(t, tt.line, formatter.ErrorLine(err))
+		})
+	}
+}
+
+func Test_Constraints_InvalidHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		err    string
+	}{
+		{name: "unknown", header: "Id[number(38,0)|primary_key]", err: "unknown constraint 'primary_key' of column 'ID', expected not_null, unique, default=<value>, enum=<value>,..., min=<value> or max=<value>"},
+		{name: "missing value", header: "Id[number(38,0)|min]", err: "unknown constraint 'min' of column 'ID', expected not_null, unique, default=<value>, enum=<value>,..., min=<value> or max=<value>"},
+		{name: "invalid bound", header: "Id[number(38,0)|min=a]", err: "min 'a' of column 'ID' is not a valid value of the column: error converting value 'a' to integer"},
+		{name: "min greater than max", header: "Id[number(38,0)|min=10|max=1]", err: "min '10' of column 'ID' is greater than its max '1'"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := csvreader.ParseCsvHeaders(reader, []string{tt.header})
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
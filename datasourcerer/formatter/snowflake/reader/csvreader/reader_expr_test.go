@@ -0,0 +1,42 @@
+package csvreader_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/expr"
+)
+
+func Test_Expr_ParseCsvHeaders(t *testing.T) {
+	t.Parallel()
+	headers, err := csvreader.ParseCsvHeaders(reader, []string{"Due[expr()]", "Payload[expr(VARIANT)]", "Amount[expr(NUMBER(10,2))]"})
+	assert.Nil(t, err)
+	assert.Len(t, headers, 3)
+	for _, header := range headers {
+		_, ok := header.(*expr.Expr)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, "", headers[0].GetType())
+	assert.Equal(t, "VARIANT", headers[1].GetType())
+	assert.Equal(t, "NUMBER(10,2)", headers[2].GetType())
+}
+
+func Test_Expr_ReadCsv(t *testing.T) {
+	t.Parallel()
+	data := strings.TrimSpace(`
+Id,Due[expr()],Payload[expr(VARIANT)]
+1,CURRENT_DATE - 1,"OBJECT_CONSTRUCT('a', 1)"
+2,,
+`)
+	content, err := reader.Read(strings.NewReader(data))
+	assert.Nil(t, err)
+
+	expected := strings.TrimSpace(`
+SELECT '1'::VARCHAR(16777216) AS ID, CURRENT_DATE - 1 AS DUE, (OBJECT_CONSTRUCT('a', 1))::VARIANT AS PAYLOAD
+UNION ALL
+SELECT '2'::VARCHAR(16777216) AS ID, NULL AS DUE, NULL::VARIANT AS PAYLOAD
+`)
+	assert.Equal(t, expected, string(content))
+}
@@ -0,0 +1,65 @@
+package csvreader_test
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader"
+)
+
+func Test_Expressions_Text_ReadCsv(t *testing.T) {
+	t.Parallel()
+	data := strings.TrimSpace(`
+"Id[number(10,0)]",Note[varchar(20)],Comment,"Due[expr(DATE)]"
+=seq(1),=seq(5),==x,CURRENT_DATE
+=seq(1),==today,=oops(,=today
+`)
+	keep := formatter.NewDefaultCsvConfig()
+	keep.Expressions.KeepText = true
+
+	tests := []struct {
+		name     string
+		config   formatter.CsvConfig
+		expected string
+	}{
+		{
+			name:   "text kept as it is",
+			config: keep,
+			expected: strings.TrimSpace(`
+SELECT 1::NUMBER(10,0) AS ID, '=seq(5)'::VARCHAR(20) AS NOTE, '==x'::VARCHAR(16777216) AS COMMENT, (CURRENT_DATE)::DATE AS DUE
+UNION ALL
+SELECT 2::NUMBER(10,0) AS ID, '==today'::VARCHAR(20) AS NOTE, '=oops('::VARCHAR(16777216) AS COMMENT, (=today)::DATE AS DUE
+`),
+		},
+		{
+			name:   "text evaluated",
+			config: formatter.NewDefaultCsvConfig(),
+			expected: strings.TrimSpace(`
+SELECT 1::NUMBER(10,0) AS ID, '5'::VARCHAR(20) AS NOTE, '=x'::VARCHAR(16777216) AS COMMENT, (CURRENT_DATE)::DATE AS DUE
+UNION ALL
+SELECT 2::NUMBER(10,0) AS ID, '=today'::VARCHAR(20) AS NOTE, '=oops('::VARCHAR(16777216) AS COMMENT, (=today)::DATE AS DUE
+`),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			reader := csvreader.NewCsvReader(slog.Default(), tt.config)
+			r := csv.NewReader(strings.NewReader(data))
+			row, err := r.Read()
+			assert.Nil(t, err)
+
+			headers, err := csvreader.ParseCsvHeaders(reader, row)
+			assert.Nil(t, err)
+
+			content, err := csvreader.ParseCsvContent(reader, r, headers)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, string(content))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package csvreader_test
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader"
+)
+
+func Test_Null_ReadCsv(t *testing.T) {
+	t.Parallel()
+	data := strings.TrimSpace(`
+"Id[number(10,0)]","Birthday[date()]",Name
+1,,
+,2000-12-31,John
+`)
+	r := csv.NewReader(strings.NewReader(data))
+	row, err := r.Read()
+	assert.Nil(t, err)
+
+	headers, err := csvreader.ParseCsvHeaders(reader, row)
+	assert.Nil(t, err)
+
+	content, err := csvreader.ParseCsvContent(reader, r, headers)
+	assert.Nil(t, err)
+
+	expected := strings.TrimSpace(`
+SELECT 1::NUMBER(10,0) AS ID, NULL::DATE AS BIRTHDAY, ''::VARCHAR(16777216) AS NAME
+UNION ALL
+SELECT NULL::NUMBER(10,0) AS ID, '2000-12-31'::DATE AS BIRTHDAY, 'John'::VARCHAR(16777216) AS NAME
+`)
+	assert.Equal(t, expected, string(content))
+}
+
+func Test_Null_ReadCsv_Marker(t *testing.T) {
+	t.Parallel()
+	data := strings.TrimSpace(`
+"Id[number(10,0)]",Name,"Status[varchar(10)|default=open]"
+\N,\N,\N
+2,,
+`)
+	r := csv.NewReader(strings.NewReader(data))
+	row, err := r.Read()
+	assert.Nil(t, err)
+
+	headers, err := csvreader.ParseCsvHeaders(reader, row)
+	assert.Nil(t, err)
+
+	content, err := csvreader.ParseCsvContent(reader, r, headers)
+	assert.Nil(t, err)
+
+	expected := strings.TrimSpace(`
+SELECT NULL::NUMBER(10,0) AS ID, NULL::VARCHAR(16777216) AS NAME, NULL::VARCHAR(10) AS STATUS
+UNION ALL
+SELECT 2::NUMBER(10,0) AS ID, ''::VARCHAR(16777216) AS NAME, 'open'::VARCHAR(10) AS STATUS
+`)
+	assert.Equal(t, expected, string(content))
+}
@@ -0,0 +1,41 @@
+package csvreader_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader"
+)
+
+func Test_Portable_ReadCsv(t *testing.T) {
+	t.Parallel()
+	data := strings.TrimSpace(`
+Id[int],"Amount[decimal(10,2)|min=0]",Name[string],Active[bool],Birthday[date],Created[timestamp],Updated[timestamptz],Payload[json]
+1,10.5,John,true,2000-12-31,2024-01-31 08:30:00,2024-01-31 08:30:00,"{""a"":1}"
+`)
+	content, err := reader.Read(strings.NewReader(data))
+	assert.Nil(t, err)
+
+	expected := "SELECT 1::NUMBER(38,0) AS ID, 10.5::NUMBER(10,2) AS AMOUNT, 'John'::VARCHAR(16777216) AS NAME, true::BOOLEAN AS ACTIVE, '2000-12-31'::DATE AS BIRTHDAY, " +
+		"'2024-01-31 08:30:00'::TIMESTAMP_NTZ(9) AS CREATED, '2024-01-31 08:30:00'::TIMESTAMP_TZ(9) AS UPDATED, PARSE_JSON('{\"a\":1}') AS PAYLOAD"
+	assert.Equal(t, expected, string(content))
+}
+
+func Test_Portable_ParseCsvHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		err    string
+	}{
+		{name: "decimal", header: "Amount[decimal(10,a)]", err: "invalid scale value: 'a'"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := csvreader.ParseCsvHeaders(reader, []string{tt.header})
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
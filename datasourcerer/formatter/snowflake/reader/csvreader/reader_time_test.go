@@ -21,7 +21,7 @@ func Test_Time_ParseCsvHeaders(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			headers, err := csvreader.ParseCsvHeaders(nil, tt.input)
+			headers, err := csvreader.ParseCsvHeaders(reader, tt.input)
 			assert.Nil(t, err)
 			assert.Len(t, headers, 3)
 			for _, header := range headers {
@@ -44,7 +44,7 @@ func Test_Time_ReadCsv(t *testing.T) {
 	row, err := r.Read()
 	assert.Nil(t, err)
 
-	headers, err := csvreader.ParseCsvHeaders(nil, row)
+	headers, err := csvreader.ParseCsvHeaders(reader, row)
 	assert.Nil(t, err)
 
 	content, err := csvreader.ParseCsvContent(nil, r, headers)
@@ -0,0 +1,99 @@
+package csvreader_test
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader"
+	"gopkg.in/yaml.v3"
+)
+
+const typesConfig = `
+types:
+  email: varchar(320)
+  money: decimal(19,4)
+  zip:
+    regex: '\d{4}'
+    sql: "'{{value}}'::POSTAL_CODE"
+  point:
+    sql: "ST_MAKEPOINT({{value}})"
+    type: GEOGRAPHY
+  label:
+    sql: "'{{value}}'::LABEL"
+  date: varchar(10)
+`
+
+func typesReader(t *testing.T) *csvreader.CsvlReader {
+	var config formatter.Config
+	assert.Nil(t, yaml.Unmarshal([]byte(typesConfig), &config))
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return csvreader.NewCsvReader(logger, formatter.NewDefaultCsvConfig()).WithTypes(config.Types)
+}
+
+func Test_Types_ParseConfig(t *testing.T) {
+	t.Parallel()
+	var config formatter.Config
+	assert.Nil(t, yaml.Unmarshal([]byte(typesConfig), &config))
+	assert.Equal(t, formatter.TypeConfig{Alias: "varchar(320)"}, config.Types["email"])
+	assert.Equal(t, formatter.TypeConfig{Regex: `\d{4}`, Sql: "'{{value}}'::POSTAL_CODE"}, config.Types["zip"])
+	assert.Equal(t, "POSTAL_CODE", config.Types["zip"].SqlType())
+	assert.Equal(t, "GEOGRAPHY", config.Types["point"].SqlType())
+
+	err := yaml.Unmarshal([]byte("types:\n  zip:\n    regex: '\\d{4}'\n    sql: POSTAL_CODE\n"), &config)
+	assert.EqualError(t, err, "line 3: the sql of a custom type must contain '{{value}}', e.g. \"'{{value}}'::MY_DOMAIN\"")
+}
+
+func Test_Types_ReadCsv(t *testing.T) {
+	t.Parallel()
+	data := strings.TrimSpace(`
+"Contact[email|not_null]","Balance[money]",Zip[zip],Location[point()],Due[date()]
+john@example.com,10.5,1234,"1, 2",2024-01-31
+jane@example.com,,,,
+`)
+	content, err := typesReader(t).Read(strings.NewReader(data))
+	assert.Nil(t, err)
+
+	// The built-in 'date' type is not shadowed by the type of the config
+	expected := strings.TrimSpace(`
+SELECT 'john@example.com'::VARCHAR(320) AS CONTACT, 10.5::NUMBER(19,4) AS BALANCE, '1234'::POSTAL_CODE AS ZIP, ST_MAKEPOINT(1, 2) AS LOCATION, '2024-01-31'::DATE AS DUE
+UNION ALL
+SELECT 'jane@example.com'::VARCHAR(320) AS CONTACT, NULL::NUMBER(19,4) AS BALANCE, NULL::POSTAL_CODE AS ZIP, NULL::GEOGRAPHY AS LOCATION, NULL::DATE AS DUE
+`)
+	assert.Equal(t, expected, string(content))
+}
+
+func Test_Types_ReadCsv_Escaped(t *testing.T) {
+	t.Parallel()
+	data := strings.TrimSpace(`
+Name[label]
+O'Brien\x
+`)
+	content, err := typesReader(t).Read(strings.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, `SELECT 'O''Brien\\x'::LABEL AS NAME`, string(content))
+}
+
+func Test_Types_Violations(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		values string
+		err    string
+	}{
+		{name: "regex", header: "Zip[zip]", values: "123", err: "error parsing value '123' for column 'ZIP' in line 2"},
+		{name: "unknown type", header: "Zip[postcode]", values: "1234", err: "unable to parse header `Zip[postcode]`"},
+		{name: "arguments", header: "Zip[zip(4)]", values: "1234", err: "invalid signature 'Zip[zip(4)]'. Signature should be of the form <name>[zip()]"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := typesReader(t).Read(strings.NewReader(tt.header + "\n" + tt.values + "\n"))
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
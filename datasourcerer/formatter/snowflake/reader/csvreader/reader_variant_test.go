@@ -0,0 +1,64 @@
+package csvreader_test
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader"
+)
+
+func Test_Variant_ReadCsv(t *testing.T) {
+	t.Parallel()
+	data := strings.TrimSpace(`
+Id,Payload[variant()]
+1,"{""a"":[1,2]}"
+2,
+3,"""text"""
+4,"{""name"":""O'Brien"",""note"":""a\nb""}"
+`)
+	r := csv.NewReader(strings.NewReader(data))
+	row, err := r.Read()
+	assert.Nil(t, err)
+
+	headers, err := csvreader.ParseCsvHeaders(reader, row)
+	assert.Nil(t, err)
+
+	content, err := csvreader.ParseCsvContent(reader, r, headers)
+	assert.Nil(t, err)
+
+	expected := strings.TrimSpace(`
+SELECT '1'::VARCHAR(16777216) AS ID, PARSE_JSON('{"a":[1,2]}') AS PAYLOAD
+UNION ALL
+SELECT '2'::VARCHAR(16777216) AS ID, NULL::VARIANT AS PAYLOAD
+UNION ALL
+SELECT '3'::VARCHAR(16777216) AS ID, PARSE_JSON('"text"') AS PAYLOAD
+UNION ALL
+SELECT '4'::VARCHAR(16777216) AS ID, PARSE_JSON('{"name":"O''Brien","note":"a\\nb"}') AS PAYLOAD
+`)
+	assert.Equal(t, expected, string(content))
+}
+
+func Test_Variant_ReadCsv_Invalid(t *testing.T) {
+	t.Parallel()
+	data := strings.TrimSpace(`
+Payload[variant()]
+{a:1}
+`)
+	r := csv.NewReader(strings.NewReader(data))
+	row, err := r.Read()
+	assert.Nil(t, err)
+
+	headers, err := csvreader.ParseCsvHeaders(reader, row)
+	assert.Nil(t, err)
+
+	_, err = csvreader.ParseCsvContent(reader, r, headers)
+	assert.EqualError(t, err, "error parsing value '{a:1}' for column 'PAYLOAD' in line 2")
+}
+
+func Test_Variant_ParseCsvHeaders(t *testing.T) {
+	t.Parallel()
+	_, err := csvreader.ParseCsvHeaders(reader, []string{"Payload[variant(1)]"})
+	assert.EqualError(t, err, "invalid signature 'Payload[variant(1)]'. Expected ()")
+}
@@ -5,7 +5,6 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/timestamp/utils"
@@ -33,17 +32,32 @@ func (t *TimestampLtz) GetName() string {
 	return t.fieldName
 }
 
-// GetWriter implements formatter.ICsvHeader.
-func (t *TimestampLtz) GetWriter() func(value interface{}) ([]byte, error) {
-	return func(value interface{}) ([]byte, error) {
+// GetType implements formatter.ICsvHeader
+func (t *TimestampLtz) GetType() string {
+	return t.timestampSignature
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (t *TimestampLtz) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
 		// Parse the timestamp based on the specified format
-		timestamp, err := time.Parse(t.format, value.(string))
+		parsed, err := formatter.ParseTime(value, t.format)
 		if err != nil {
-			return nil, fmt.Errorf("not able to convert value '%s' to timestamp using the '%s' format", value.(string), t.format)
+			return "", fmt.Errorf("not able to convert value '%s' to timestamp using the '%s' format", formatter.StringValue(value), t.format)
 		}
 
 		// Convert the timestamp to a string in the default timestamp format
-		timestampString := timestamp.Format(defaultTimestampFormat)
+		return parsed.Format(defaultTimestampFormat), nil
+	}
+}
+
+// GetWriter implements formatter.ICsvHeader.
+func (t *TimestampLtz) GetWriter() func(value interface{}) ([]byte, error) {
+	return func(value interface{}) ([]byte, error) {
+		timestampString, err := t.GetCsvWriter()(value)
+		if err != nil {
+			return nil, err
+		}
 
 		// Return the formatted timestamp string with the appropriate Snowflake type and alias
 		return []byte(fmt.Sprintf("'%s'::%s AS %s", timestampString, t.timestampSignature, t.fieldName)), nil
@@ -30,11 +30,23 @@ func (m *Varchar) GetName() string {
 	return m.fieldName
 }
 
+// GetType implements formatter.ICsvHeader
+func (m *Varchar) GetType() string {
+	return fmt.Sprintf("VARCHAR(%d)", m.bytes)
+}
+
 // TODO: refactor
+// GetCsvWriter implements formatter.ICsvHeader.
+func (v *Varchar) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		return formatter.StringValue(value), nil
+	}
+}
+
 // GetWriter implements formatter.ICsvHeader.
 func (v *Varchar) GetWriter() func(value interface{}) ([]byte, error) {
 	return func(value interface{}) ([]byte, error) {
-		return []byte(fmt.Sprintf("'%s'::VARCHAR(%d) AS %s", value, v.bytes, strings.ToUpper(v.fieldName))), nil
+		return []byte(fmt.Sprintf("'%s'::VARCHAR(%d) AS %s", formatter.StringValue(value), v.bytes, strings.ToUpper(v.fieldName))), nil
 	}
 }
 
@@ -1,37 +1,78 @@
 package variant
 
-// import (
-// 	"regexp"
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
 
-// 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
-// )
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
 
-// var _ formatter.ICsvHeader = &Number{}
+var _ formatter.ICsvHeader = &Variant{}
 
-// // Signature must contains "[boolean" (case insensitive) at any position and ends with ")]"
-// var snowflakeNumberSignatureRegex = regexp.MustCompile(`^(\w+)\[number\((.*?)\)\]$`)
+// Signature must contains "[variant" (case insensitive) at any position and ends with ")]"
+var variantSignatureRegex = regexp.MustCompile(`(?i)^(\w+)\[variant\((.*?)\)\]$`)
 
-// const SnowflakeNumberSignature = "[number("
+const (
+	SnowflakeVariantSignaturePrefix = "[variant("
+)
 
-// type Number struct {
-// 	fieldName string
-// 	precision int
-// 	scale     int
-// }
+// literalEscaper escapes a json document for a single quoted Snowflake string literal, in which the backslash is an escape character as well
+var literalEscaper = strings.NewReplacer(`\`, `\\`, `'`, `''`)
 
-// // GetName implements formatter.ICsvHeader
-// func (m *Number) GetName() string {
-// 	return m.fieldName
-// }
+// Variant is signified with "[variant()]". The values are json documents, parsed with PARSE_JSON
+type Variant struct {
+	fieldName string
+}
 
-// // GetWriter implements formatter.ICsvHeader.
-// func (b *Number) GetWriter() func(value interface{}) []byte {
-// 	return func(value interface{}) []byte {
-// 		return []byte(value.(string))
-// 	}
-// }
+// GetName implements formatter.ICsvHeader
+func (m *Variant) GetName() string {
+	return m.fieldName
+}
 
-// // GetWriter implements formatter.ICsvHeader.
-// func (b *Number) ParseHeader(signature string) error {
-// 	return nil
-// }
+// GetType implements formatter.ICsvHeader
+func (m *Variant) GetType() string {
+	return "VARIANT"
+}
+
+// GetCsvWriter implements formatter.ICsvHeader.
+func (v *Variant) GetCsvWriter() func(value interface{}) (string, error) {
+	return func(value interface{}) (string, error) {
+		document := formatter.StringValue(value)
+		if !json.Valid([]byte(document)) {
+			return "", fmt.Errorf("value '%s' is not a valid json document", document)
+		}
+		return document, nil
+	}
+}
+
+// GetWriter implements formatter.ICsvHeader.
+func (v *Variant) GetWriter() func(value interface{}) ([]byte, error) {
+	return func(value interface{}) ([]byte, error) {
+		document, err := v.GetCsvWriter()(value)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(fmt.Sprintf("PARSE_JSON('%s') AS %s", literalEscaper.Replace(document), v.fieldName)), nil
+	}
+}
+
+// ParseHeader implements formatter.ICsvHeader.
+func (v *Variant) ParseHeader(signature string) error {
+	if !strings.HasSuffix(signature, "]") {
+		return fmt.Errorf("invalid signature '%s'. Signature should be of the form <name>[variant()]", signature)
+	}
+
+	if count := strings.Count(signature, "(") - strings.Count(signature, ")"); count != 0 {
+		return fmt.Errorf("unbalanced parentheses in signature '%s'", signature)
+	}
+
+	matches := variantSignatureRegex.FindStringSubmatch(signature)
+	if len(matches) != 3 || strings.TrimSpace(matches[2]) != "" {
+		return fmt.Errorf("invalid signature '%s'. Expected ()", signature)
+	}
+
+	v.fieldName = strings.ToUpper(strings.TrimSpace(matches[1]))
+	return nil
+}
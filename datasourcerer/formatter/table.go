@@ -0,0 +1,83 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// Table is a parsed csv data source: its columns and rows, with every value both as a sql fragment and as a normalized csv value
+type Table struct {
+	Columns []Column
+	Rows    []Row
+}
+
+// Column is the name and sql type of a data source column
+type Column struct {
+	Name string
+	Type string
+	Expr bool // The values are sql expressions, which only a sql mock can evaluate
+}
+
+// Row is a record of the data source. A row may have fewer cells than the table has columns
+type Row struct {
+	Line   int    // 1-based line of the record in the data source
+	Source string // The data source of the record when the rows of several data sources are concatenated into one mock
+	Cells  []Cell
+}
+
+// Cell is a value of a row
+type Cell struct {
+	Value string // The value normalized by the column parser, e.g. a date in the default date format
+	Sql   []byte // The value as a typed and aliased sql fragment, e.g. '2000-12-31'::DATE AS FOO
+	Null  bool   // The value is NULL: '\N', or an empty value of a typed column. An empty value of a text column is an empty string
+}
+
+// Sql renders the rows as 'SELECT ...' statements joined by 'UNION ALL', without a trailing line break
+func (t *Table) Sql() []byte {
+	var buffer bytes.Buffer
+	for i, row := range t.Rows {
+		if i > 0 {
+			buffer.WriteString("UNION ALL\n")
+		}
+		buffer.WriteString("SELECT ")
+		for j, cell := range row.Cells {
+			if j > 0 {
+				buffer.WriteString(", ")
+			}
+			buffer.Write(cell.Sql)
+		}
+		buffer.WriteByte('\n')
+	}
+	return bytes.TrimRight(buffer.Bytes(), "\n")
+}
+
+// WriteCsv writes the column names and the normalized values of the rows as csv, using the separator between fields.
+// NULL values are written as NullValue, so that they differ from empty strings. A table with an expression column cannot be written as csv
+func (t *Table) WriteCsv(writer io.Writer, separator rune) error {
+	w := csv.NewWriter(writer)
+	w.Comma = separator
+	names := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		if column.Expr {
+			return fmt.Errorf("column '%s' holds sql expressions, which cannot be written as csv. Use 'input_format': 'sql'", column.Name)
+		}
+		names[i] = column.Name
+	}
+	records := [][]string{names}
+	for _, row := range t.Rows {
+		record := make([]string, len(row.Cells))
+		for i, cell := range row.Cells {
+			record[i] = cell.Value
+			if cell.Null {
+				record[i] = NullValue
+			}
+		}
+		records = append(records, record)
+	}
+	if err := w.WriteAll(records); err != nil {
+		return fmt.Errorf("error writing csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// typeRegex matches an annotated header with a type without arguments, e.g. 'contact[email]', 'status[my_status()|not_null]'
+var typeRegex = regexp.MustCompile(`^(.*?)\[\s*(\w+)\s*(?:\(\s*\))?\s*(\|[^\]]*)?\]\s*$`)
+
+// castRegex matches the cast at the end of a sql template, e.g. '::MY_DOMAIN' of "'{{value}}'::MY_DOMAIN"
+var castRegex = regexp.MustCompile(`::\s*([\w.]+(?:\([^)]*\))?)\s*$`)
+
+// Types are the column types of the config, keyed by the name the headers are annotated with, e.g. 'contact[email]'
+type Types map[string]TypeConfig
+
+// TypeConfig is a column type of the config: an alias of a type of the dialect, e.g. 'email: varchar(320)',
+// or a custom type whose values must match a regex and are rendered with a sql template, e.g. {regex: '^\d{4}$', sql: "'{{value}}'::MY_DOMAIN"}
+type TypeConfig struct {
+	Alias string `yaml:"-"`     //The annotated type the alias stands for, e.g. 'number(19,4)'
+	Regex string `yaml:"regex"` //The pattern every value of the custom type must match. Any value matches when empty
+	Sql   string `yaml:"sql"`   //The sql of a value of the custom type, with '{{value}}' replaced by the value. Its single quotes are escaped, so that '{{value}}' can be quoted
+	Type  string `yaml:"type"`  //The sql type of the NULL values of the custom type. Defaults to the cast at the end of the sql, e.g. 'MY_DOMAIN'
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler: a scalar is an alias and a mapping is a custom type
+func (t *TypeConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&t.Alias)
+	}
+	type plain TypeConfig
+	if err := value.Decode((*plain)(t)); err != nil {
+		return err
+	}
+	if !strings.Contains(t.Sql, "{{value}}") {
+		return fmt.Errorf("line %d: the sql of a custom type must contain '{{value}}', e.g. \"'{{value}}'::MY_DOMAIN\"", value.Line)
+	}
+	return nil
+}
+
+// SqlType returns the sql type of the custom type, or an empty string when neither its type nor the cast of its sql is set
+func (t TypeConfig) SqlType() string {
+	if t.Type != "" {
+		return t.Type
+	}
+	if match := castRegex.FindStringSubmatch(t.Sql); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// Pattern compiles the regex of the custom type, anchored to match the whole value
+func (t TypeConfig) Pattern() (*regexp.Regexp, error) {
+	if t.Regex == "" {
+		return regexp.MustCompile(`(?s)^.*$`), nil
+	}
+	pattern, err := regexp.Compile(`^(?:` + t.Regex + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex '%s': %w", t.Regex, err)
+	}
+	return pattern, nil
+}
+
+// Resolve returns the header with its alias replaced by the aliased type, keeping the constraints, e.g. 'contact[varchar(320)|not_null]' for 'contact[email|not_null]'.
+// A custom type gets the arguments of the signature of its parser, e.g. 'status[my_status()]' for 'status[my_status]'. Other headers are returned unchanged
+func (t Types) Resolve(header string) string {
+	match := typeRegex.FindStringSubmatch(header)
+	if match == nil {
+		return header
+	}
+	name, typ, constraints := match[1], match[2], match[3]
+	for key, config := range t {
+		if !strings.EqualFold(key, typ) {
+			continue
+		}
+		if config.Alias != "" {
+			return fmt.Sprintf("%s[%s%s]", name, config.Alias, constraints)
+		}
+		return fmt.Sprintf("%s[%s()%s]", name, typ, constraints)
+	}
+	return header
+}
@@ -0,0 +1,32 @@
+package formatter
+
+import (
+	"fmt"
+	"time"
+)
+
+// NullValue is the csv value of NULL in any column. An empty value is NULL in a typed column as well, but an empty string in a text column
+const NullValue = `\N`
+
+// ParseTime returns the time of a cell value: a time evaluated from a cell expression such as '=today-3d' as is, and a string parsed with the layout
+func ParseTime(value interface{}, layout string) (time.Time, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+	return time.Parse(layout, StringValue(value))
+}
+
+// StringValue returns a cell value as text. Times evaluated from cell expressions are written as dates when they are at midnight, and as RFC 3339 timestamps otherwise
+func StringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		if v.Equal(time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, v.Location())) {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,174 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/datasourceparser"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+)
+
+type FileStatus string
+
+const (
+	FileStatusUpToDate FileStatus = "up-to-date"
+	FileStatusStale    FileStatus = "stale"
+	FileStatusMissing  FileStatus = "missing"
+	FileStatusOrphaned FileStatus = "orphaned"
+)
+
+// FileCheck is the result of comparing a generated test file with what the generator would produce
+type FileCheck struct {
+	Path     string
+	Template string
+	Status   FileStatus
+	// Current is the content of the generated test file on disk. Empty when the file is missing
+	Current []byte
+	// Rendered is the content the generator would write. Empty when the file is orphaned
+	Rendered []byte
+}
+
+// Check renders every test template in memory and compares the result byte-for-byte with the generated test files.
+// Nothing is written to disk. When includeOrphans is set, generated files without a matching test template are reported as orphaned.
+// The result is sorted by path.
+func (s *Generator) Check(testTemplateFiles *[]templatecrawler.TestTemplateFile, dataSourceFiles *map[string]datasourceparser.DataSourceFile, includeOrphans bool) ([]FileCheck, error) {
+	type checkJob struct {
+		testFilePath     string
+		testTemplateFile *templatecrawler.TestTemplateFile
+	}
+	jobs := make(chan checkJob)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var checks []FileCheck
+	var renderErrors []error
+
+	for i := 0; i < s.workers; i++ {
+		go func() {
+			for j := range jobs {
+				check, err := s.checkFile(j.testFilePath, j.testTemplateFile, dataSourceFiles)
+				mu.Lock()
+				if err != nil {
+					s.logger.Error(fmt.Sprintf("error rendering test file '%s': %s", j.testFilePath, err.Error()))
+					s.issues.Add(report.Issue{Stage: report.StageGenerate, File: j.testFilePath, Template: j.testTemplateFile.AbsFilePath(), Message: err.Error()})
+					renderErrors = append(renderErrors, err)
+				} else {
+					checks = append(checks, check)
+				}
+				mu.Unlock()
+				wg.Done()
+			}
+		}()
+	}
+
+	expected := map[string]bool{}
+	for i := range *testTemplateFiles {
+		templateFile := &(*testTemplateFiles)[i]
+		if templateFile.Failed() {
+			continue
+		}
+		testFilePath := path.Join(s.outputAbsDir, templateFile.RelativeFilePath())
+		expected[testFilePath] = true
+		wg.Add(1)
+		jobs <- checkJob{testFilePath: testFilePath, testTemplateFile: templateFile}
+	}
+	wg.Wait()
+	close(jobs)
+
+	if len(renderErrors) > 0 {
+		return nil, fmt.Errorf("unable to render %d test file(s)", len(renderErrors))
+	}
+
+	if includeOrphans {
+		orphans, err := s.FindOrphans(testTemplateFiles)
+		if err != nil {
+			return nil, err
+		}
+		for _, orphan := range orphans {
+			current, _ := os.ReadFile(orphan)
+			checks = append(checks, FileCheck{Path: orphan, Status: FileStatusOrphaned, Current: current})
+		}
+	}
+
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Path < checks[j].Path })
+	return checks, nil
+}
+
+func (s *Generator) checkFile(testFilePath string, templateFile *templatecrawler.TestTemplateFile, dataSourceFiles *map[string]datasourceparser.DataSourceFile) (FileCheck, error) {
+	check := FileCheck{Path: testFilePath, Template: templateFile.AbsFilePath()}
+
+	var rendered bytes.Buffer
+	if err := s.render(&rendered, testFilePath, templateFile, dataSourceFiles); err != nil {
+		return check, err
+	}
+	check.Rendered = rendered.Bytes()
+
+	current, err := os.ReadFile(testFilePath)
+	switch {
+	case err != nil:
+		check.Status = FileStatusMissing
+	case bytes.Equal(current, check.Rendered):
+		check.Current = current
+		check.Status = FileStatusUpToDate
+	default:
+		check.Current = current
+		check.Status = FileStatusStale
+	}
+	return check, nil
+}
+
+// FindOrphans returns the files in the output directory that carry the generated header but no longer map to one of the test templates
+func (s *Generator) FindOrphans(testTemplateFiles *[]templatecrawler.TestTemplateFile) ([]string, error) {
+	expected := map[string]bool{}
+	for i := range *testTemplateFiles {
+		expected[path.Join(s.outputAbsDir, (*testTemplateFiles)[i].RelativeFilePath())] = true
+	}
+
+	var orphans []string
+	err := filepath.WalkDir(s.outputAbsDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && filePath == s.outputAbsDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || expected[filePath] {
+			return nil
+		}
+		generated, err := isGeneratedFile(filePath)
+		if err != nil {
+			return err
+		}
+		if generated {
+			orphans = append(orphans, filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error looking for orphaned test files in '%s': %w", s.outputAbsDir, err)
+	}
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+// isGeneratedFile reports whether the file starts with the generated header
+func isGeneratedFile(filePath string) (bool, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header := make([]byte, len(generatedHeader))
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false, nil
+	}
+	return bytes.Equal(header, []byte(generatedHeader)), nil
+}
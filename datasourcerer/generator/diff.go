@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/datasourceparser"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+)
+
+// DiffSummary counts the generated test files by how a generation would change them
+type DiffSummary struct {
+	Created   int
+	Modified  int
+	Unchanged int
+}
+
+// Changed returns the number of generated test files a generation would write
+func (d DiffSummary) Changed() int {
+	return d.Created + d.Modified
+}
+
+func (d DiffSummary) String() string {
+	return fmt.Sprintf("%d test file(s) would change: %d created, %d modified, %d unchanged", d.Changed(), d.Created, d.Modified, d.Unchanged)
+}
+
+// Diff renders every test template in memory and writes a unified diff against the generated test file for each file a generation would change.
+// Nothing is written to disk.
+func (s *Generator) Diff(w io.Writer, testTemplateFiles *[]templatecrawler.TestTemplateFile, dataSourceFiles *map[string]datasourceparser.DataSourceFile) (DiffSummary, error) {
+	summary := DiffSummary{}
+	checks, err := s.Check(testTemplateFiles, dataSourceFiles, false)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, check := range checks {
+		rel, err := filepath.Rel(s.outputAbsDir, check.Path)
+		if err != nil {
+			rel = check.Path
+		}
+		from, to := "a/"+filepath.ToSlash(rel), "b/"+filepath.ToSlash(rel)
+
+		switch check.Status {
+		case FileStatusUpToDate:
+			summary.Unchanged++
+			continue
+		case FileStatusMissing:
+			summary.Created++
+			from = "/dev/null"
+		case FileStatusStale:
+			summary.Modified++
+		}
+		if err := unifiedDiff(w, from, to, check.Current, check.Rendered); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/datasourceparser"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+)
+
+// ErrorMode decides how a data source that failed to parse is rendered into the generated test
+type ErrorMode string
+
+const (
+	ErrorModeInline ErrorMode = "inline" // the error message is written into the mock body
+	ErrorModeRaise  ErrorMode = "raise"  // the mock body raises a dbt compiler error with the error message
+	ErrorModeStrict ErrorMode = "strict" // the test file is not written
+)
+
+func ParseErrorMode(mode string) (ErrorMode, error) {
+	switch ErrorMode(strings.ToLower(mode)) {
+	case ErrorModeInline:
+		return ErrorModeInline, nil
+	case ErrorModeRaise:
+		return ErrorModeRaise, nil
+	case ErrorModeStrict:
+		return ErrorModeStrict, nil
+	default:
+		return "", fmt.Errorf("unknown error mode '%s', expected one of 'inline', 'raise' or 'strict'", mode)
+	}
+}
+
+// raiseCompilerError returns the jinja statement failing the dbt compilation of a test with the data source error.
+// The data source path is relative to the test template, like the 'source_file' it was referenced by
+func raiseCompilerError(templateFile *templatecrawler.TestTemplateFile, ds *datasourceparser.DataSourceFile) string {
+	file, err := filepath.Rel(templateFile.AbsFileDir(), ds.FilePath())
+	if err != nil {
+		file = ds.FilePath()
+	}
+	location := filepath.ToSlash(file)
+	if line := formatter.ErrorLine(ds.Err()); line > 0 {
+		location = fmt.Sprintf("%s:%d", location, line)
+	}
+	message := fmt.Sprintf("datasourcerer: %s: %s", location, ds.Err().Error())
+	return fmt.Sprintf(`{{ exceptions.raise_compiler_error("%s") }}`, jinjaStringEscaper.Replace(message))
+}
+
+// jinjaStringEscaper escapes a message for a double quoted jinja string literal
+var jinjaStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
@@ -2,26 +2,33 @@ package generator
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"sync"
-	"time"
 
-	"github.com/samber/lo"
 	"github.com/tsanton/dbt-unit-test-fusionizer/datasourceparser"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
 	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+	"github.com/tsanton/dbt-unit-test-fusionizer/utilities"
 )
 
 type Generator struct {
 	logger       *slog.Logger
 	outputAbsDir string
 	workers      int
+	lock         *LockFile
+	errorMode    ErrorMode
+	issues       report.Collector
+	mu           sync.Mutex
+	generated    int
 }
 
 func NewTestGenerator(logger *slog.Logger, workerCount int, initDir string) *Generator {
@@ -30,26 +37,58 @@ func NewTestGenerator(logger *slog.Logger, workerCount int, initDir string) *Gen
 		logger:       logger,
 		outputAbsDir: outputAbsDir,
 		workers:      workerCount,
+		errorMode:    ErrorModeInline,
 	}
 }
 
+// WithLockFile makes the generator skip tests whose template, data sources, config and output still match the hashes recorded in the lock.
+// Without a lock file every test is rendered, and only written when its content changed.
+func (s *Generator) WithLockFile(lock *LockFile) *Generator {
+	s.lock = lock
+	return s
+}
+
+// WithErrorMode sets how data sources that failed to parse are rendered. Defaults to ErrorModeInline
+func (s *Generator) WithErrorMode(mode ErrorMode) *Generator {
+	s.errorMode = mode
+	return s
+}
+
+// Issues returns the errors raised while generating the test files
+func (s *Generator) Issues() []report.Issue {
+	return s.issues.Issues()
+}
+
+// Generated returns the number of test files written by the generator
+func (s *Generator) Generated() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generated
+}
+
 type mergeFileJob struct {
 	testFilePath     string
 	testTemplateFile *templatecrawler.TestTemplateFile
 	dataSources      *map[string]datasourceparser.DataSourceFile
+	lockEntry        LockEntry
 }
 
 func (s *Generator) Generate(testTemplateFiles *[]templatecrawler.TestTemplateFile, dataSourceFiles *map[string]datasourceparser.DataSourceFile) error {
 	jobs := make(chan mergeFileJob)
 	var wg sync.WaitGroup
+	failed := 0
 
 	// Create worker goroutines
 	for i := 0; i < s.workers; i++ {
 		go func() {
 			for j := range jobs {
-				err := s.mergeFiles(j.testFilePath, j.testTemplateFile, j.dataSources)
+				err := s.mergeFiles(j.testFilePath, j.testTemplateFile, j.dataSources, j.lockEntry)
 				if err != nil {
-					s.logger.Error(fmt.Sprintf("error generating test file '%s': %s", j.testFilePath, err.Error())) //Debug here is ok, because it's already logged in processDataSource
+					s.logger.Error(fmt.Sprintf("error generating test file '%s': %s", j.testFilePath, err.Error()))
+					s.issues.Add(report.Issue{Stage: report.StageGenerate, File: j.testFilePath, Template: j.testTemplateFile.AbsFilePath(), Message: err.Error()})
+					s.mu.Lock()
+					failed++
+					s.mu.Unlock()
 				} else {
 					s.logger.Debug(fmt.Sprintf("successfully generated test file '%s'", j.testFilePath))
 				}
@@ -58,67 +97,65 @@ func (s *Generator) Generate(testTemplateFiles *[]templatecrawler.TestTemplateFi
 		}()
 	}
 	s.logger.Info(fmt.Sprintf("total of %d test template(s) found", len(*testTemplateFiles)))
-	for _, templateFile := range *testTemplateFiles {
-		templateFileCopy := templateFile
-		lastDataSourceTouched := time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
-		for _, ref := range *templateFile.DataSourceReferences() {
-			ds := (*dataSourceFiles)[ref.DataSourceFilePath]
-			if ds.LastChanged().After(lastDataSourceTouched) {
-				lastDataSourceTouched = ds.LastChanged()
+	for i := range *testTemplateFiles {
+		templateFile := &(*testTemplateFiles)[i]
+		if templateFile.Failed() {
+			continue
+		}
+		testFilePath := path.Join(s.outputAbsDir, templateFile.RelativeFilePath())
+
+		var lockEntry LockEntry
+		if s.lock != nil {
+			lockEntry = s.lock.entryFor(templateFile, dataSourceFiles)
+			recorded, ok := s.lock.get(filepath.ToSlash(templateFile.RelativeFilePath()))
+			if ok && recorded.upToDate(lockEntry, testFilePath) {
+				s.logger.Debug(fmt.Sprintf("test file '%s' does not need regeneration", testFilePath))
+				continue
 			}
+			s.logger.Debug(fmt.Sprintf("test template, data source(s) or test file '%s' changed since the last generation. Recreating...", testFilePath))
 		}
-		s.logger.Debug(fmt.Sprintf("data source was last updated: '%s'", lastDataSourceTouched.Format(time.RFC3339)))
 
-		testFilePath := path.Join(s.outputAbsDir, templateFile.RelativeFilePath())
-		exist, testFileLastUpdated := fileLastTouched(testFilePath)
-		s.logger.Debug(fmt.Sprintf("test file '%s' exist: %t.", testFilePath, exist))
-		if !exist {
-			s.logger.Debug(fmt.Sprintf("test file '%s' does not exist. Creating...", testFilePath))
-			wg.Add(1)
-			go func(tf templatecrawler.TestTemplateFile) {
-				jobs <- mergeFileJob{
-					testFilePath:     testFilePath,
-					testTemplateFile: &tf,
-					dataSources:      dataSourceFiles,
-				}
-			}(templateFileCopy)
-
-		} else if lastDataSourceTouched.After(templateFile.LastChanged()) && lastDataSourceTouched.After(testFileLastUpdated) {
-			s.logger.Debug("data source file(s) altered after last test template generation. Recreating...")
-			wg.Add(1)
-			go func(tf templatecrawler.TestTemplateFile) {
-				jobs <- mergeFileJob{
-					testFilePath:     testFilePath,
-					testTemplateFile: &tf,
-					dataSources:      dataSourceFiles,
-				}
-			}(templateFileCopy)
-		} else if testFileLastUpdated.Before(templateFile.LastChanged()) {
-			s.logger.Debug("test template was altered after the last test generation. Recreating...")
-			wg.Add(1)
-			go func(tf templatecrawler.TestTemplateFile) {
-				jobs <- mergeFileJob{
-					testFilePath:     testFilePath,
-					testTemplateFile: &tf,
-					dataSources:      dataSourceFiles,
-				}
-			}(templateFileCopy)
-		} else {
-			s.logger.Debug(fmt.Sprintf("test file '%s' does not need regeneration", testFilePath))
+		wg.Add(1)
+		jobs <- mergeFileJob{
+			testFilePath:     testFilePath,
+			testTemplateFile: templateFile,
+			dataSources:      dataSourceFiles,
+			lockEntry:        lockEntry,
 		}
 	}
 	wg.Wait()
 	close(jobs) // Close jobs channel after all jobs have been processed
+
+	if s.lock != nil {
+		if err := s.lock.Save(); err != nil {
+			s.issues.Add(report.Issue{Stage: report.StageGenerate, File: s.lock.path, Message: err.Error()})
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to generate %d test file(s)", failed)
+	}
 	return nil
 }
 
-func (s *Generator) mergeFiles(targetFilePath string, templateFile *templatecrawler.TestTemplateFile, dataSources *map[string]datasourceparser.DataSourceFile) error {
-	sourceFile, err := os.Open(templateFile.AbsFilePath())
-	if err != nil {
-		s.logger.Error(fmt.Sprintf("error opening source file: '%s'", err.Error()))
+// generatedHeader is prepended to every generated test file and identifies it as owned by the datasourcerer
+var generatedHeader = strings.TrimSpace(`
+/*###############################################
+### Do NOT modify: generated by datasourcerer ###
+###############################################*/
+`) + "\n"
+
+func (s *Generator) mergeFiles(targetFilePath string, templateFile *templatecrawler.TestTemplateFile, dataSources *map[string]datasourceparser.DataSourceFile, lockEntry LockEntry) error {
+	var content bytes.Buffer
+	if err := s.render(&content, targetFilePath, templateFile, dataSources); err != nil {
 		return err
 	}
-	defer sourceFile.Close()
+	if s.lock == nil {
+		if current, err := os.ReadFile(targetFilePath); err == nil && bytes.Equal(current, content.Bytes()) {
+			s.logger.Debug(fmt.Sprintf("test file '%s' does not need regeneration", targetFilePath))
+			return nil
+		}
+	}
 
 	// Create the directory if it does not exist
 	s.logger.Debug(fmt.Sprintf("making directory (if not exists): '%s'", filepath.Dir(targetFilePath)))
@@ -128,125 +165,198 @@ func (s *Generator) mergeFiles(targetFilePath string, templateFile *templatecraw
 	}
 
 	s.logger.Debug(fmt.Sprintf("creating file: '%s'", targetFilePath))
-	targetFile, err := os.Create(targetFilePath)
-	if err != nil {
+	if err := os.WriteFile(targetFilePath, content.Bytes(), 0644); err != nil {
 		s.logger.Error(fmt.Sprintf("Error creating target file: '%s'", err.Error()))
 		return err
 	}
-	defer targetFile.Close()
-	sourceReader := bufio.NewReader(sourceFile)
-	targetWriter := bufio.NewWriter(targetFile)
+
+	if s.lock != nil {
+		lockEntry.Output = utilities.Sha256(content.Bytes())
+		s.lock.set(filepath.ToSlash(templateFile.RelativeFilePath()), lockEntry)
+	}
+	s.mu.Lock()
+	s.generated++
+	s.mu.Unlock()
+	return nil
+}
+
+// render writes the generated test for the template file, with its data sources inserted, to the writer
+func (s *Generator) render(w io.Writer, targetFilePath string, templateFile *templatecrawler.TestTemplateFile, dataSources *map[string]datasourceparser.DataSourceFile) error {
+	content := templateFile.Content()
+	targetWriter := bufio.NewWriter(w)
 	defer targetWriter.Flush()
-	_, err = targetWriter.WriteString(strings.TrimSpace(`
-/*###############################################
-### Do NOT modify: generated by datasourcerer ###
-###############################################*/
-`) + "\n")
-	if err != nil {
+	if _, err := targetWriter.WriteString(generatedHeader); err != nil {
 		return err
 	}
 
-	dataSourceReferences := templateFile.DataSourceReferences()
-	var endCallLines []int
-	var wrappedLines []int
-	for _, dsr := range *dataSourceReferences {
-		endCallLines = append(endCallLines, dsr.EndCallLine)
-		if dsr.CallLine == dsr.EndCallLine {
-			wrappedLines = append(wrappedLines, dsr.CallLine)
+	references := append([]templatecrawler.DataSourceReference{}, *templateFile.DataSourceReferences()...)
+	sort.SliceStable(references, func(i, j int) bool { return references[i].EndCallOffset < references[j].EndCallOffset })
+
+	pos := 0
+	for i := range references {
+		dsr := &references[i]
+		offset := dsr.EndCallOffset
+		if offset < pos || offset > len(content) {
+			return fmt.Errorf("invalid offset %d of the 'endcall' in line %d", offset, dsr.EndCallLine)
+		}
+		lineStart := bytes.LastIndexByte(content[:offset], '\n') + 1
+		beforeEndCall := content[lineStart:offset]
+		indentation := beforeEndCall[:len(beforeEndCall)-len(bytes.TrimLeft(beforeEndCall, " \t"))]
+
+		if dsr.InlineContent != nil {
+			// Inline data source: replace the body of the call with the rendered data source
+			if dsr.BodyOffset < pos || dsr.BodyOffset > offset {
+				return fmt.Errorf("invalid offset %d of the body of the call in line %d", dsr.BodyOffset, dsr.CallLine)
+			}
+			if _, err := targetWriter.Write(content[pos:dsr.BodyOffset]); err != nil {
+				return err
+			}
+			if err := targetWriter.WriteByte('\n'); err != nil {
+				return err
+			}
+			pos = offset
+			if len(indentation) == len(beforeEndCall) {
+				pos = lineStart
+			}
+		} else if len(indentation) == len(beforeEndCall) && lineStart >= pos {
+			// '{% endcall %}' on its own line: insert the data source on the lines before it
+			s.logger.Debug(fmt.Sprintf("inserting data source '%s' in file '%s', line %d", strings.Join(dsr.DataSourceFilePaths, "', '"), targetFilePath, dsr.EndCallLine))
+			if _, err := targetWriter.Write(content[pos:lineStart]); err != nil {
+				return err
+			}
+			pos = lineStart
+		} else {
+			// '{% endcall %}' after other content, e.g. '{% call ... %}{% endcall %}': break the line and indent the 'endcall' like the line
+			s.logger.Debug(fmt.Sprintf("inserting wrapped data source '%s' in file '%s', line %d", strings.Join(dsr.DataSourceFilePaths, "', '"), targetFilePath, dsr.EndCallLine))
+			if _, err := targetWriter.Write(content[pos:offset]); err != nil {
+				return err
+			}
+			if err := targetWriter.WriteByte('\n'); err != nil {
+				return err
+			}
+			pos = offset
 		}
-	}
-	sourceLineIndex := 1 // Initialize line counter
-	for {
-		line, readErr := sourceReader.ReadString('\n')
 
-		if readErr != nil && readErr != io.EOF {
-			s.logger.Error(fmt.Sprintf("error creating target file: '%s'", err.Error()))
+		if err := s.insertDataSource(targetWriter, templateFile, dsr, dataSources); err != nil {
+			s.logger.Debug(fmt.Sprintf("error inserting data source in file: '%s', line: %d. Error: %s", targetFilePath, dsr.EndCallLine, err.Error()))
 			return err
 		}
-		if lo.Contains(endCallLines, sourceLineIndex) {
-			dsr, found := lo.Find[templatecrawler.DataSourceReference](*dataSourceReferences, func(x templatecrawler.DataSourceReference) bool { return x.EndCallLine == sourceLineIndex })
-			if !found {
-				return fmt.Errorf("error finding data source reference for line %d", sourceLineIndex)
+		if pos == offset {
+			if _, err := targetWriter.Write(indentation); err != nil {
+				return err
 			}
+		}
+	}
+	_, err := targetWriter.Write(content[pos:])
+	return err
+}
 
-			if lo.Contains(wrappedLines, sourceLineIndex) {
-				s.logger.Debug(fmt.Sprintf("inserting wrapped data source '%s' in file '%s', line %d", dsr.DataSourceFilePath, targetFilePath, sourceLineIndex))
-				err = handleWrappedLine(targetWriter, line, &dsr, dataSources)
-				if err != nil {
-					s.logger.Debug(fmt.Sprintf("error handling wrapped call and endline data insert in file '%s', line %d. Error: %s", targetFilePath, sourceLineIndex, err.Error()))
-					return err
-				}
-			} else {
-				s.logger.Debug(fmt.Sprintf("inserting data source '%s' in file '%s', line %d", dsr.DataSourceFilePath, targetFilePath, sourceLineIndex))
-				err = insertDataSource(targetWriter, &dsr, dataSources)
-				if err != nil {
-					s.logger.Debug(fmt.Sprintf("error inserting data source in file: '%s', line: %d. Error: %s", targetFilePath, sourceLineIndex, err.Error()))
-					return err
-				}
-				_, err := targetWriter.WriteString(line)
-				if err != nil {
-					s.logger.Debug(fmt.Sprintf("error writing line after inserted datasource in file '%s', line %d. Error: %s", targetFilePath, sourceLineIndex, err.Error()))
+func (s *Generator) insertDataSource(targetWriter *bufio.Writer, templateFile *templatecrawler.TestTemplateFile, dsr *templatecrawler.DataSourceReference, dataSources *map[string]datasourceparser.DataSourceFile) error {
+	files := make([]datasourceparser.DataSourceFile, len(dsr.DataSourceFilePaths))
+	for i, path := range dsr.DataSourceFilePaths {
+		files[i] = (*dataSources)[path]
+		if files[i].Err() != nil {
+			return s.insertDataSourceError(targetWriter, templateFile, path, &files[i])
+		}
+	}
+	if err := matchColumns(dsr.DataSourceFilePaths, files); err != nil {
+		return err
+	}
+
+	if dsr.InputFormat != formatter.ParserInputTypeCsv && dsr.Selection == nil {
+		for i := range files {
+			if i > 0 {
+				if _, err := targetWriter.WriteString("UNION ALL\n"); err != nil {
 					return err
 				}
 			}
-		} else {
-			_, err := targetWriter.WriteString(line)
-			if err != nil {
-				s.logger.Debug(fmt.Sprintf("error writing line in file '%s', line %d. Error: %s", targetFilePath, sourceLineIndex, err.Error()))
-				return err
+			if err := files[i].Formatter.Write(targetWriter); err != nil {
+				return fmt.Errorf("data source '%s': %w", dsr.DataSourceFilePaths[i], err)
 			}
 		}
+		return nil
+	}
 
-		// End of file is reached.
-		if readErr == io.EOF {
-			break
+	table, err := concatTables(files, dsr)
+	if err != nil {
+		return err
+	}
+	if dsr.Selection != nil {
+		if table, err = dsr.Selection.Apply(table); err != nil {
+			return fmt.Errorf("error selecting from data source '%s': %w", strings.Join(dsr.DataSourceFilePaths, "', '"), err)
 		}
-		sourceLineIndex++ // Increment line counter
 	}
-	return nil
+	if dsr.InputFormat == formatter.ParserInputTypeCsv {
+		return table.WriteCsv(targetWriter, dsr.ColumnSeparator)
+	}
+	if len(table.Rows) == 0 {
+		return fmt.Errorf("no rows of data source '%s' are selected, a sql mock needs at least one row", strings.Join(dsr.DataSourceFilePaths, "', '"))
+	}
+	if _, err := targetWriter.Write(table.Sql()); err != nil {
+		return err
+	}
+	return targetWriter.WriteByte('\n')
 }
 
-func fileLastTouched(filePath string) (bool, time.Time) {
-	file, err := os.Stat(filePath)
-	if err != nil {
-		return false, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+// insertDataSourceError renders a data source that failed to parse according to the error mode
+func (s *Generator) insertDataSourceError(targetWriter *bufio.Writer, templateFile *templatecrawler.TestTemplateFile, path string, ds *datasourceparser.DataSourceFile) error {
+	switch s.errorMode {
+	case ErrorModeRaise:
+		_, err := targetWriter.WriteString(raiseCompilerError(templateFile, ds) + "\n")
+		return err
+	case ErrorModeStrict:
+		return fmt.Errorf("data source '%s' failed to parse: %w", path, ds.Err())
+	default:
+		return ds.Formatter.Write(targetWriter)
 	}
-	return true, file.ModTime()
 }
 
-// handle same line call and endcall: {% call ..... %}{% endcall %}
-func handleWrappedLine(targetWriter *bufio.Writer, line string, dsr *templatecrawler.DataSourceReference, dataSources *map[string]datasourceparser.DataSourceFile) error {
-	endRegex := regexp.MustCompile(regexp.QuoteMeta("{% endcall %}"))
-	loc := endRegex.FindStringIndex(line)
-	if loc == nil {
-		return fmt.Errorf("error finding '{%% endcall %%}' in line %s", line)
-	} else {
-		beforeMatch := line[:loc[0]]
-		afterMatch := line[loc[0]:]
-
-		// Calculate leading whitespace for consistent indentation
-		leadingWhitespace := beforeMatch[:len(beforeMatch)-len(strings.TrimLeft(beforeMatch, " \t"))]
-
-		// Append the line before the match, then append the content, and finally the rest of the line
-		if _, err := targetWriter.WriteString(beforeMatch + "\n"); err != nil {
-			return err
+// concatTables returns one table with the rows of every data source of the mock.
+// Only data sources with known rows and columns, i.e. csv files, can be written as csv or have rows selected
+func concatTables(files []datasourceparser.DataSourceFile, dsr *templatecrawler.DataSourceReference) (*formatter.Table, error) {
+	table := &formatter.Table{}
+	for i := range files {
+		t := files[i].Formatter.Table()
+		if t == nil {
+			if dsr.InputFormat == formatter.ParserInputTypeCsv {
+				return nil, fmt.Errorf("data source '%s' cannot be written as csv, only csv data sources support 'input_format' csv", dsr.DataSourceFilePaths[i])
+			}
+			return nil, fmt.Errorf("data source '%s' does not support 'rows', 'where' or 'columns', only csv data sources do", dsr.DataSourceFilePaths[i])
 		}
-
-		ds := (*dataSources)[dsr.DataSourceFilePath]
-		if err := ds.Formatter.Write(targetWriter); err != nil {
-			return err
+		if i == 0 {
+			table.Columns = t.Columns
 		}
-
-		indentedAfterMatch := leadingWhitespace + afterMatch
-		if _, err := targetWriter.WriteString(indentedAfterMatch); err != nil {
-			return err
+		for _, row := range t.Rows {
+			row.Source = files[i].FilePath()
+			table.Rows = append(table.Rows, row)
 		}
-
-		return nil
 	}
+	return table, nil
 }
 
-func insertDataSource(targetWriter *bufio.Writer, dsr *templatecrawler.DataSourceReference, dataSources *map[string]datasourceparser.DataSourceFile) error {
-	ds := (*dataSources)[dsr.DataSourceFilePath]
-	return ds.Formatter.Write(targetWriter)
+// matchColumns verifies that the data sources of a mock have the same column names and types, so that their rows can be concatenated.
+// Data sources without known columns, e.g. sql files, are not verified
+func matchColumns(paths []string, files []datasourceparser.DataSourceFile) error {
+	var first *formatter.Table
+	var firstPath string
+	for i := range files {
+		table := files[i].Formatter.Table()
+		if table == nil {
+			continue
+		}
+		if first == nil {
+			first, firstPath = table, paths[i]
+			continue
+		}
+		if len(table.Columns) != len(first.Columns) {
+			return fmt.Errorf("data source '%s' has %d column(s), but '%s' has %d", paths[i], len(table.Columns), firstPath, len(first.Columns))
+		}
+		for j, column := range table.Columns {
+			expected := first.Columns[j]
+			if !strings.EqualFold(column.Name, expected.Name) || !strings.EqualFold(column.Type, expected.Type) {
+				return fmt.Errorf("column %d of data source '%s' is '%s %s', but '%s' defines '%s %s'", j+1, paths[i], column.Name, column.Type, firstPath, expected.Name, expected.Type)
+			}
+		}
+	}
+	return nil
 }
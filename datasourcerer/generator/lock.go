@@ -0,0 +1,159 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/datasourceparser"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+	"github.com/tsanton/dbt-unit-test-fusionizer/utilities"
+)
+
+// LockFile records the content hashes each generated test was generated from, and is persisted as JSON (.datasourcerer.lock).
+// A generated test is only regenerated when one of the recorded hashes no longer matches.
+type LockFile struct {
+	path string
+	mu   sync.Mutex
+
+	Version string               `json:"version"`
+	Config  string               `json:"config"`
+	Tests   map[string]LockEntry `json:"tests"`
+}
+
+// LockEntry holds the hashes of a single generated test, keyed by the test template path relative to the template directory
+type LockEntry struct {
+	Template string                 `json:"template"`
+	Fixtures map[string]FixtureLock `json:"fixtures,omitempty"`
+	Output   string                 `json:"output"`
+}
+
+// FixtureLock holds the hashes of a referenced data source, keyed by its path relative to the lock file
+type FixtureLock struct {
+	Content string `json:"content"`
+	Config  string `json:"config"`
+}
+
+// LoadLockFile reads the lock file at path. A missing lock file results in an empty lock.
+// The version and config hash describe the running generation: a lock recorded with another version or config is discarded.
+func LoadLockFile(path string, version string, configHash string) (*LockFile, error) {
+	lock := &LockFile{
+		path:    path,
+		Version: version,
+		Config:  configHash,
+		Tests:   map[string]LockEntry{},
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lock, nil
+		}
+		return nil, fmt.Errorf("error reading lock file '%s': %w", path, err)
+	}
+
+	recorded := &LockFile{}
+	if err := json.Unmarshal(content, recorded); err != nil {
+		return nil, fmt.Errorf("error parsing lock file '%s': %w", path, err)
+	}
+	if recorded.Version == version && recorded.Config == configHash && recorded.Tests != nil {
+		lock.Tests = recorded.Tests
+	}
+	return lock, nil
+}
+
+// Save writes the lock file as indented JSON with sorted keys
+func (l *LockFile) Save() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing lock file: %w", err)
+	}
+	if err := os.WriteFile(l.path, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing lock file '%s': %w", l.path, err)
+	}
+	return nil
+}
+
+// Retain drops the entries of test templates that are not in the list, i.e. templates that were deleted or renamed
+func (l *LockFile) Retain(testTemplateFiles *[]templatecrawler.TestTemplateFile) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	keep := map[string]bool{}
+	for i := range *testTemplateFiles {
+		keep[filepath.ToSlash((*testTemplateFiles)[i].RelativeFilePath())] = true
+	}
+	for key := range l.Tests {
+		if !keep[key] {
+			delete(l.Tests, key)
+		}
+	}
+}
+
+func (l *LockFile) get(key string) (LockEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.Tests[key]
+	return entry, ok
+}
+
+func (l *LockFile) set(key string, entry LockEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Tests[key] = entry
+}
+
+// entryFor computes the lock entry of the test template from the current template and data source content. The output hash is left empty
+func (l *LockFile) entryFor(templateFile *templatecrawler.TestTemplateFile, dataSourceFiles *map[string]datasourceparser.DataSourceFile) LockEntry {
+	entry := LockEntry{
+		Template: templateFile.Hash(),
+		Fixtures: map[string]FixtureLock{},
+	}
+	for _, ref := range *templateFile.DataSourceReferences() {
+		for _, path := range ref.DataSourceFilePaths {
+			ds := (*dataSourceFiles)[path]
+			entry.Fixtures[l.relativePath(path)] = FixtureLock{
+				Content: ds.Hash(),
+				Config:  ds.ConfigHash(),
+			}
+			// A data source extending a base is regenerated when the base changes
+			for _, dependency := range ds.Dependencies() {
+				base := (*dataSourceFiles)[dependency]
+				entry.Fixtures[l.relativePath(dependency)] = FixtureLock{
+					Content: base.Hash(),
+					Config:  base.ConfigHash(),
+				}
+			}
+		}
+	}
+	return entry
+}
+
+// relativePath makes the path relative to the lock file, so that the lock is portable between checkouts
+func (l *LockFile) relativePath(path string) string {
+	rel, err := filepath.Rel(filepath.Dir(l.path), path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// upToDate reports whether the recorded entry matches the expected entry and the generated output on disk
+func (e LockEntry) upToDate(expected LockEntry, outputPath string) bool {
+	if e.Template != expected.Template || len(e.Fixtures) != len(expected.Fixtures) {
+		return false
+	}
+	for path, fixture := range expected.Fixtures {
+		if e.Fixtures[path] != fixture {
+			return false
+		}
+	}
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		return false
+	}
+	return utilities.Sha256(output) == e.Output
+}
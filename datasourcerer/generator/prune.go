@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+)
+
+// Prune deletes the generated test files that no longer map to one of the test templates, and the directories left empty by it.
+// With dryRun set the orphaned files are only returned. The test templates must be the result of an unfiltered crawl.
+// Nothing is pruned when the crawl raised issues, as a test template the crawler could not reach would make its generated test look orphaned.
+func (s *Generator) Prune(testTemplateFiles *[]templatecrawler.TestTemplateFile, crawlIssues []report.Issue, dryRun bool) ([]string, error) {
+	if len(crawlIssues) > 0 {
+		return nil, fmt.Errorf("refusing to prune: the crawler reported %d issue(s)", len(crawlIssues))
+	}
+	orphans, err := s.FindOrphans(testTemplateFiles)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return orphans, nil
+	}
+
+	for _, orphan := range orphans {
+		s.logger.Debug(fmt.Sprintf("deleting orphaned test file '%s'", orphan))
+		if err := os.Remove(orphan); err != nil {
+			s.logger.Error(fmt.Sprintf("error deleting orphaned test file '%s': %s", orphan, err.Error()))
+			return nil, err
+		}
+		s.removeEmptyDirs(filepath.Dir(orphan))
+	}
+	return orphans, nil
+}
+
+// removeEmptyDirs removes dir and its parents while they are empty, stopping at the output directory
+func (s *Generator) removeEmptyDirs(dir string) {
+	for dir != s.outputAbsDir && dir != filepath.Dir(dir) {
+		rel, err := filepath.Rel(s.outputAbsDir, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			return
+		}
+		s.logger.Debug(fmt.Sprintf("deleting empty directory '%s'", dir))
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
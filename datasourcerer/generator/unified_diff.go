@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const diffContextLines = 3
+
+type diffOp struct {
+	kind byte // ' ' (equal), '-' (delete) or '+' (insert)
+	text string
+}
+
+// unifiedDiff writes the line based difference between a and b in the unified diff format. Nothing is written when a equals b
+func unifiedDiff(w io.Writer, fromName, toName string, a, b []byte) error {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	// aPos and bPos hold the number of lines of a and b preceding each operation
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	changed := false
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != '+' {
+			aPos[i+1]++
+		}
+		if op.kind != '-' {
+			bPos[i+1]++
+		}
+		if op.kind != ' ' {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", fromName, toName); err != nil {
+		return err
+	}
+
+	i := 0
+	for i < len(ops) {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		// Merge changes that are separated by less than twice the context into the same hunk
+		lastChange := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				lastChange = j
+			} else if j-lastChange > 2*diffContextLines {
+				break
+			}
+		}
+		end := lastChange + diffContextLines + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		aCount, bCount := aPos[end]-aPos[start], bPos[end]-bPos[start]
+		aStart, bStart := aPos[start], bPos[start]
+		if aCount > 0 {
+			aStart++
+		}
+		if bCount > 0 {
+			bStart++
+		}
+		if _, err := fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount); err != nil {
+			return err
+		}
+		for _, op := range ops[start:end] {
+			line := string(op.kind) + op.text
+			if !strings.HasSuffix(op.text, "\n") {
+				line += "\n\\ No newline at end of file\n"
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+		i = end
+	}
+	return nil
+}
+
+// splitLines splits the content into lines, keeping the line endings
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes the shortest edit script turning a into b using the Myers algorithm
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		// Only the diagonals reachable within d edits are needed when backtracking
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(trace, a, b)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrackDiff(trace [][]int, a, b []string) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		window := d + 1
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[window+k-1] < v[window+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[window+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{kind: ' ', text: a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, diffOp{kind: '+', text: b[y-1]})
+			} else {
+				ops = append(ops, diffOp{kind: '-', text: a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	// The operations were collected from the end of the files
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected string
+	}{
+		{
+			name:     "Equal",
+			a:        "a\nb\n",
+			b:        "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "Created",
+			a:    "",
+			b:    "a\nb\n",
+			expected: strings.TrimLeft(`
+--- from
++++ to
+@@ -0,0 +1,2 @@
++a
++b
+`, "\n"),
+		},
+		{
+			name: "Modified",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n11\n12\n13\n",
+			expected: strings.TrimLeft(`
+--- from
++++ to
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`, "\n"),
+		},
+		{
+			name: "MergedHunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n",
+			b:    "one\n2\n3\n4\n5\n6\nseven\n",
+			expected: strings.TrimLeft(`
+--- from
++++ to
+@@ -1,7 +1,7 @@
+-1
++one
+ 2
+ 3
+ 4
+ 5
+ 6
+-7
++seven
+`, "\n"),
+		},
+		{
+			name: "NoNewlineAtEndOfFile",
+			a:    "a\nb",
+			b:    "a\nc",
+			expected: strings.TrimLeft(`
+--- from
++++ to
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+\ No newline at end of file
+`, "\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buffer bytes.Buffer
+			err := unifiedDiff(&buffer, "from", "to", []byte(tt.a), []byte(tt.b))
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, buffer.String())
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/datasourceparser"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/relationship"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+)
+
+// Validate checks the relationships between the mocks of every test: each foreign key value of a mock must be a value of the mock it references.
+// A relationship is only checked in the tests that mock both models, and a mock is checked with the rows and columns it selects.
+// It returns the violations as issues and the number of checked mocks
+func (s *Generator) Validate(testTemplateFiles *[]templatecrawler.TestTemplateFile, dataSourceFiles *map[string]datasourceparser.DataSourceFile, relationships []relationship.Relationship) ([]report.Issue, int) {
+	var issues []report.Issue
+	checked := 0
+	for i := range *testTemplateFiles {
+		templateFile := &(*testTemplateFiles)[i]
+		if templateFile.Failed() {
+			continue
+		}
+		references := *templateFile.DataSourceReferences()
+
+		// The mocks of a test are related to each other, and not to the mocks of the other tests of the template
+		var tests []int
+		mocks := map[int][]*templatecrawler.DataSourceReference{}
+		for j := range references {
+			line := references[j].TestLine
+			if _, ok := mocks[line]; !ok {
+				tests = append(tests, line)
+			}
+			mocks[line] = append(mocks[line], &references[j])
+		}
+
+		for _, test := range tests {
+			for _, r := range relationships {
+				var from []*templatecrawler.DataSourceReference
+				var to []*formatter.Table
+				for _, dsr := range mocks[test] {
+					if r.From.Matches(dsr.Mock) {
+						from = append(from, dsr)
+					}
+					if !r.To.Matches(dsr.Mock) {
+						continue
+					}
+					table, err := mockTable(dsr, dataSourceFiles)
+					if err != nil {
+						issues = append(issues, s.validateIssue(templateFile, dsr, r, err))
+					}
+					if table == nil {
+						// Data sources that failed to parse are reported by the parser
+						from = nil
+						break
+					}
+					to = append(to, table)
+				}
+				if len(to) == 0 {
+					continue
+				}
+
+				for _, dsr := range from {
+					table, err := mockTable(dsr, dataSourceFiles)
+					if err != nil {
+						issues = append(issues, s.validateIssue(templateFile, dsr, r, err))
+					}
+					if table == nil {
+						continue
+					}
+					violations, err := relationship.Check(r, table, to)
+					if err != nil {
+						issues = append(issues, s.validateIssue(templateFile, dsr, r, err))
+						continue
+					}
+					checked++
+					for _, violation := range violations {
+						message := fmt.Sprintf("value '%s' of '%s' has no matching '%s' in the mocks of the test", violation.Value, r.From, r.To)
+						s.logger.Error(message)
+						issues = append(issues, report.Issue{
+							Stage:      report.StageValidate,
+							File:       violation.Row.Source,
+							Template:   templateFile.AbsFilePath(),
+							Line:       violation.Row.Line,
+							ColumnName: r.From.Column,
+							Value:      violation.Value,
+							Message:    message,
+						})
+					}
+				}
+			}
+		}
+	}
+	return issues, checked
+}
+
+// validateIssue returns the issue of a relationship that cannot be checked for a mock
+func (s *Generator) validateIssue(templateFile *templatecrawler.TestTemplateFile, dsr *templatecrawler.DataSourceReference, r relationship.Relationship, err error) report.Issue {
+	message := fmt.Sprintf("relationship '%s' cannot be checked: %s", r, err.Error())
+	s.logger.Error(message)
+	return report.Issue{Stage: report.StageValidate, File: templateFile.AbsFilePath(), Line: dsr.CallLine, Message: message}
+}
+
+// mockTable returns the rows and columns of a mock: its data sources concatenated, with the 'rows', 'where' and 'columns' selection applied.
+// The table is nil when a data source failed to parse
+func mockTable(dsr *templatecrawler.DataSourceReference, dataSources *map[string]datasourceparser.DataSourceFile) (*formatter.Table, error) {
+	files := make([]datasourceparser.DataSourceFile, len(dsr.DataSourceFilePaths))
+	for i, path := range dsr.DataSourceFilePaths {
+		files[i] = (*dataSources)[path]
+		if files[i].Err() != nil {
+			return nil, nil
+		}
+	}
+	if err := matchColumns(dsr.DataSourceFilePaths, files); err != nil {
+		return nil, err
+	}
+	table, err := concatTables(files, &templatecrawler.DataSourceReference{DataSourceFilePaths: dsr.DataSourceFilePaths, InputFormat: formatter.ParserInputTypeCsv})
+	if err != nil {
+		return nil, fmt.Errorf("only csv data sources have rows that can be checked, '%s' is not", strings.Join(dsr.DataSourceFilePaths, "', '"))
+	}
+	if dsr.Selection != nil {
+		if table, err = dsr.Selection.Apply(table); err != nil {
+			return nil, fmt.Errorf("error selecting from data source '%s': %w", strings.Join(dsr.DataSourceFilePaths, "', '"), err)
+		}
+	}
+	return table, nil
+}
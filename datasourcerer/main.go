@@ -1,22 +1,36 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/tsanton/dbt-unit-test-fusionizer/datasourceparser"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/postgres"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake"
 	"github.com/tsanton/dbt-unit-test-fusionizer/generator"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
 	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+	"github.com/tsanton/dbt-unit-test-fusionizer/utilities"
 )
 
 var (
 	logger *slog.Logger
 	run    *mainConfig
+	// version and commit are set at build time through ldflags
+	version = "dev"
+	commit  = "none"
+	// commands are the subcommands, e.g. 'datasourcerer synth -schema users.yaml'. Without one, datasourcerer generates the unit tests
+	commands = map[string]func(args []string) int{
+		"synth":     runSynth,
+		"translate": runTranslate,
+		"validate":  runValidate,
+	}
 )
 
 func init() {
@@ -41,7 +55,8 @@ func init() {
 		}
 	}
 
-	logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: loggingLevel}))
+	// The logs go to stderr, so that stdout only holds the output of the run mode, e.g. the unified diff of '--diff' or the csv of 'synth'
+	logger = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: loggingLevel}))
 	slog.SetDefault(logger)
 
 	/* Define flags */
@@ -49,6 +64,12 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
 	/* Parse flags */
 	flag.Parse()
 
@@ -59,6 +80,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if run.prune && len(run.cases) > 0 {
+		logger.Error("the 'prune' flag cannot be combined with 'case': orphaned tests can only be determined from all test templates")
+		os.Exit(1)
+	}
+
 	/* Parse default config file */
 	err = run.parseConfigFile(logger)
 	if err != nil {
@@ -67,9 +93,71 @@ func main() {
 	}
 
 	c := make(chan templatecrawler.DataSourceReference)
-	crawler := templatecrawler.NewTestTemplateCrawler(logger, run.crawlers, run.templateDir)
+	adapter, err := templatecrawler.NewAdapter(run.config.Mocks)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	crawler := templatecrawler.NewTestTemplateCrawler(logger, run.crawlers, run.templateDir).WithCases(run.cases).WithAdapter(adapter)
+	if len(run.cases) > 0 {
+		logger.Info(fmt.Sprintf("limiting test generation to case(s): %s", run.cases.String()))
+	}
 
-	var dataSources *map[string]datasourceparser.DataSourceFile
+	dataSources, parserIssues, err := parseDataSources(crawler, c)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	//Generate the dbt files with formatted inputted data
+	templates := crawler.GetTestTemplates()
+	rep := report.New()
+	rep.Add(crawler.Issues()...)
+	rep.Add(parserIssues...)
+	rep.Templates = len(*templates)
+	rep.DataSources = len(*dataSources)
+
+	generator := generator.NewTestGenerator(logger, run.generators, run.unitTestDir).WithErrorMode(run.errorMode)
+	if run.diff {
+		summary, err := generator.Diff(os.Stdout, templates, dataSources)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(finish(rep, generator, 1))
+		}
+		fmt.Fprintln(os.Stderr, summary.String())
+		if !run.check {
+			os.Exit(finish(rep, generator, 0))
+		}
+	}
+	if run.check {
+		os.Exit(finish(rep, generator, check(generator, templates, dataSources)))
+	}
+	if run.prune && run.dryRun {
+		os.Exit(finish(rep, generator, prune(generator, templates, crawler.Issues(), true)))
+	}
+	lock, err := loadLockFile()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	if len(run.cases) == 0 {
+		lock.Retain(templates)
+	}
+	code := 0
+	if err := generator.WithLockFile(lock).Generate(templates, dataSources); err != nil {
+		logger.Error(err.Error())
+		code = 1
+	}
+	rep.Generated = generator.Generated()
+	if run.prune && prune(generator, templates, crawler.Issues(), false) != 0 {
+		code = 1
+	}
+	logger.Info("Finished")
+	os.Exit(finish(rep, generator, code))
+}
+
+// parseDataSources crawls the test templates and parses the data sources they reference with the formatter of the configured dialect
+func parseDataSources(crawler *templatecrawler.TemplateCrawler, c chan templatecrawler.DataSourceReference) (*map[string]datasourceparser.DataSourceFile, []report.Issue, error) {
 	switch run.config.Dialect {
 	case "snowflake":
 		parser := datasourceparser.NewDatasourceParser(
@@ -80,7 +168,7 @@ func main() {
 		)
 		go crawler.Crawl(c)
 		parser.Parse(c)
-		dataSources = parser.GetDataSources()
+		return parser.GetDataSources(), parser.Issues(), nil
 	case "postgres":
 		parser := datasourceparser.NewDatasourceParser(
 			logger,
@@ -90,15 +178,104 @@ func main() {
 		)
 		go crawler.Crawl(c)
 		parser.Parse(c)
-		dataSources = parser.GetDataSources()
+		return parser.GetDataSources(), parser.Issues(), nil
 	default:
-		logger.Error(fmt.Sprintf("dialect type '%s' not supported.", run.config.Dialect))
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("dialect type '%s' not supported", run.config.Dialect)
 	}
+}
 
-	//Generate the dbt files with formatted inputted data
-	templates := crawler.GetTestTemplates()
-	generator := generator.NewTestGenerator(logger, run.generators, run.unitTestDir)
-	_ = generator.Generate(templates, dataSources)
-	logger.Info("Finished")
+// finish completes the report with the generator issues, prints the summary or diagnostics and writes the JSON report when requested.
+// It returns the process exit code, which is non-zero when the run mode failed or any template or data source had an error
+func finish(rep *report.Report, g *generator.Generator, code int) int {
+	rep.Add(g.Issues()...)
+	if run.diagnostics == "json" {
+		if err := rep.WriteSarif(os.Stderr, version); err != nil {
+			logger.Error(fmt.Sprintf("error writing diagnostics: %s", err.Error()))
+		}
+	} else if err := rep.WriteSummary(os.Stderr); err != nil {
+		logger.Error(fmt.Sprintf("error writing summary: %s", err.Error()))
+	}
+	if run.reportPath != "" {
+		if err := rep.WriteJSON(run.reportPath); err != nil {
+			logger.Error(err.Error())
+			code = 1
+		}
+	}
+	if rep.Failed() && code == 0 {
+		return 1
+	}
+	return code
+}
+
+// prune lists the orphaned generated tests and deletes them unless dryRun is set. It returns the process exit code
+func prune(g *generator.Generator, templates *[]templatecrawler.TestTemplateFile, crawlIssues []report.Issue, dryRun bool) int {
+	orphans, err := g.Prune(templates, crawlIssues, true)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	for _, orphan := range orphans {
+		rel, err := filepath.Rel(run.unitTestDir, orphan)
+		if err != nil {
+			rel = orphan
+		}
+		fmt.Printf("orphaned: %s\n", rel)
+	}
+	if dryRun {
+		fmt.Printf("%d orphaned test file(s) would be deleted\n", len(orphans))
+		return 0
+	}
+
+	orphans, err = g.Prune(templates, crawlIssues, false)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	fmt.Printf("%d orphaned test file(s) deleted\n", len(orphans))
+	return 0
+}
+
+// check compares the generated tests with the test templates and data sources, and returns the process exit code
+func check(g *generator.Generator, templates *[]templatecrawler.TestTemplateFile, dataSources *map[string]datasourceparser.DataSourceFile) int {
+	// Orphans can only be determined when every test template has been crawled
+	checks, err := g.Check(templates, dataSources, len(run.cases) == 0)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	outdated := 0
+	for _, c := range checks {
+		if c.Status == generator.FileStatusUpToDate {
+			continue
+		}
+		outdated++
+		rel, err := filepath.Rel(run.unitTestDir, c.Path)
+		if err != nil {
+			rel = c.Path
+		}
+		fmt.Printf("%s: %s\n", c.Status, rel)
+	}
+
+	if outdated > 0 {
+		fmt.Printf("%d of %d generated test file(s) are out of date. Run datasourcerer to regenerate them\n", outdated, len(checks))
+		return 1
+	}
+	fmt.Printf("all %d generated test file(s) are up to date\n", len(checks))
+	return 0
+}
+
+// loadLockFile reads the lock file for the running version and effective config.
+// The config without a reference date of the expressions is hashed with the current date, so that the data sources with '=today' are regenerated, and checked, every day
+func loadLockFile() (*generator.LockFile, error) {
+	effective := run.config
+	if effective.CSV.Expressions.ReferenceDate == "" {
+		effective.CSV.Expressions.ReferenceDate = time.Now().UTC().Format("2006-01-02")
+	}
+	config, err := json.Marshal(effective)
+	if err != nil {
+		return nil, fmt.Errorf("error serializing config: %w", err)
+	}
+	logger.Debug(fmt.Sprintf("using lock file '%s' (datasourcerer %s, commit %s)", run.lockPath, version, commit))
+	return generator.LoadLockFile(run.lockPath, version, utilities.Sha256(config))
 }
@@ -0,0 +1,121 @@
+package relationship
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
+
+// Relationship is a foreign key from a column of a mock to a column of another mock of the same test, e.g. 'orders.customer_id -> customers.id'
+type Relationship struct {
+	From Key
+	To   Key
+}
+
+// Key is a column of a mocked model or source, e.g. 'customers.id' or 'raw.customers.id'
+type Key struct {
+	Mock   string
+	Column string
+}
+
+func (k Key) String() string {
+	return k.Mock + "." + k.Column
+}
+
+func (r Relationship) String() string {
+	return r.From.String() + " -> " + r.To.String()
+}
+
+// Violation is a row of the referencing mock whose foreign key value is not in the referenced mock
+type Violation struct {
+	Row   formatter.Row
+	Value string
+}
+
+// Parse parses a relationship of the config, '<mock>.<column> -> <mock>.<column>'. The mock of a source is '<source>.<table>'
+func Parse(relationship string) (Relationship, error) {
+	from, to, ok := strings.Cut(relationship, "->")
+	if !ok {
+		return Relationship{}, fmt.Errorf("invalid relationship '%s', expected '<mock>.<column> -> <mock>.<column>'", relationship)
+	}
+	fromKey, err := parseKey(from)
+	if err != nil {
+		return Relationship{}, fmt.Errorf("invalid relationship '%s': %w", relationship, err)
+	}
+	toKey, err := parseKey(to)
+	if err != nil {
+		return Relationship{}, fmt.Errorf("invalid relationship '%s': %w", relationship, err)
+	}
+	return Relationship{From: fromKey, To: toKey}, nil
+}
+
+// ParseAll parses the relationships of the config
+func ParseAll(relationships []string) ([]Relationship, error) {
+	result := make([]Relationship, 0, len(relationships))
+	for _, r := range relationships {
+		relationship, err := Parse(r)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, relationship)
+	}
+	return result, nil
+}
+
+func parseKey(key string) (Key, error) {
+	key = strings.TrimSpace(key)
+	dot := strings.LastIndex(key, ".")
+	if dot <= 0 || dot == len(key)-1 {
+		return Key{}, fmt.Errorf("'%s' is not a '<mock>.<column>'", key)
+	}
+	return Key{Mock: strings.TrimSpace(key[:dot]), Column: strings.TrimSpace(key[dot+1:])}, nil
+}
+
+// Matches reports whether a mock is the mock of the key. Names are compared case-insensitively
+func (k Key) Matches(mock string) bool {
+	return strings.EqualFold(k.Mock, mock)
+}
+
+// Check returns the rows of the referencing table whose foreign key value is not a value of the referenced column in any of the referenced tables.
+// Empty values, i.e. NULL foreign keys, reference nothing and are not checked
+func Check(relationship Relationship, from *formatter.Table, to []*formatter.Table) ([]Violation, error) {
+	values := map[string]bool{}
+	for _, table := range to {
+		index, err := columnIndex(table, relationship.To)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range table.Rows {
+			if index < len(row.Cells) {
+				values[row.Cells[index].Value] = true
+			}
+		}
+	}
+
+	index, err := columnIndex(from, relationship.From)
+	if err != nil {
+		return nil, err
+	}
+	var violations []Violation
+	for _, row := range from.Rows {
+		if index >= len(row.Cells) || row.Cells[index].Value == "" {
+			continue
+		}
+		if value := row.Cells[index].Value; !values[value] {
+			violations = append(violations, Violation{Row: row, Value: value})
+		}
+	}
+	return violations, nil
+}
+
+func columnIndex(table *formatter.Table, key Key) (int, error) {
+	names := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		if strings.EqualFold(column.Name, key.Column) {
+			return i, nil
+		}
+		names[i] = column.Name
+	}
+	return -1, fmt.Errorf("column '%s' not found in mock '%s', expected one of '%s'", key.Column, key.Mock, strings.Join(names, "', '"))
+}
@@ -0,0 +1,67 @@
+package relationship_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/relationship"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected relationship.Relationship
+		err      string
+	}{
+		{name: "model", input: "orders.customer_id -> customers.id", expected: relationship.Relationship{From: relationship.Key{Mock: "orders", Column: "customer_id"}, To: relationship.Key{Mock: "customers", Column: "id"}}},
+		{name: "source", input: "orders.customer_id->raw.customers.id", expected: relationship.Relationship{From: relationship.Key{Mock: "orders", Column: "customer_id"}, To: relationship.Key{Mock: "raw.customers", Column: "id"}}},
+		{name: "no arrow", input: "orders.customer_id customers.id", err: "invalid relationship 'orders.customer_id customers.id', expected '<mock>.<column> -> <mock>.<column>'"},
+		{name: "no column", input: "orders -> customers.id", err: "invalid relationship 'orders -> customers.id': 'orders' is not a '<mock>.<column>'"},
+		{name: "empty column", input: "orders.customer_id -> customers.", err: "invalid relationship 'orders.customer_id -> customers.': 'customers.' is not a '<mock>.<column>'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := relationship.Parse(tt.input)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, r)
+		})
+	}
+}
+
+func table(columns []string, rows ...[]string) *formatter.Table {
+	t := &formatter.Table{}
+	for _, column := range columns {
+		t.Columns = append(t.Columns, formatter.Column{Name: column})
+	}
+	for i, values := range rows {
+		row := formatter.Row{Line: i + 2}
+		for _, value := range values {
+			row.Cells = append(row.Cells, formatter.Cell{Value: value})
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+func Test_Check(t *testing.T) {
+	r, err := relationship.Parse("orders.customer_id -> customers.id")
+	assert.Nil(t, err)
+	orders := table([]string{"ID", "CUSTOMER_ID"}, []string{"10", "1"}, []string{"11", ""}, []string{"12", "3"}, []string{"13"})
+	customers := []*formatter.Table{
+		table([]string{"ID", "NAME"}, []string{"1", "John"}),
+		table([]string{"ID", "NAME"}, []string{"2", "Jane"}),
+	}
+
+	violations, err := relationship.Check(r, orders, customers)
+	assert.Nil(t, err)
+	assert.Equal(t, []relationship.Violation{{Row: orders.Rows[2], Value: "3"}}, violations)
+
+	_, err = relationship.Check(r, table([]string{"ID"}), customers)
+	assert.EqualError(t, err, "column 'customer_id' not found in mock 'orders', expected one of 'ID'")
+}
@@ -0,0 +1,158 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+type Stage string
+
+const (
+	StageCrawl    Stage = "crawl"
+	StageParse    Stage = "parse"
+	StageGenerate Stage = "generate"
+	StageValidate Stage = "validate"
+)
+
+// Issue is a single error raised while crawling test templates, parsing data sources or generating tests
+type Issue struct {
+	Stage       Stage  `json:"stage"`
+	File        string `json:"file"`
+	Template    string `json:"template,omitempty"`
+	Message     string `json:"message"`
+	Line        int    `json:"line,omitempty"`        // 1-based line in File, 0 if unknown
+	Column      int    `json:"column,omitempty"`      // 1-based byte column in the line, 0 if unknown
+	EndColumn   int    `json:"endColumn,omitempty"`   // 1-based byte column after the offending value, 0 if unknown
+	ColumnIndex int    `json:"columnIndex,omitempty"` // 1-based index of the offending CSV column
+	ColumnName  string `json:"columnName,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Snippet     string `json:"snippet,omitempty"` // The offending line with the value underlined by carets
+}
+
+// Location returns the issue file with its line and column, as 'file:line:column'
+func (i Issue) Location() string {
+	switch {
+	case i.Line > 0 && i.Column > 0:
+		return fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Column)
+	case i.Line > 0:
+		return fmt.Sprintf("%s:%d", i.File, i.Line)
+	default:
+		return i.File
+	}
+}
+
+func (i Issue) String() string {
+	message := i.Message
+	if i.ColumnIndex > 0 {
+		message = fmt.Sprintf("%s (column %d)", message, i.ColumnIndex)
+	}
+	if i.Template != "" && i.Template != i.File {
+		return fmt.Sprintf("[%s] %s (referenced by %s): %s", i.Stage, i.Location(), i.Template, message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", i.Stage, i.Location(), message)
+}
+
+// Collector gathers issues from concurrent workers
+type Collector struct {
+	mu     sync.Mutex
+	issues []Issue
+}
+
+func (c *Collector) Add(issues ...Issue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.issues = append(c.issues, issues...)
+}
+
+// Issues returns a copy of the collected issues
+func (c *Collector) Issues() []Issue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Issue(nil), c.issues...)
+}
+
+// Report aggregates the issues of every stage of a run together with what was processed
+type Report struct {
+	Collector
+	Templates   int `json:"templates"`
+	DataSources int `json:"dataSources"`
+	Generated   int `json:"generated"`
+}
+
+func New() *Report {
+	return &Report{}
+}
+
+// Failed reports whether any template or data source failed
+func (r *Report) Failed() bool {
+	return len(r.Issues()) > 0
+}
+
+// sortedIssues returns the distinct issues ordered by stage and file
+func (r *Report) sortedIssues() []Issue {
+	seen := map[Issue]bool{}
+	var issues []Issue
+	for _, issue := range r.Issues() {
+		if !seen[issue] {
+			seen[issue] = true
+			issues = append(issues, issue)
+		}
+	}
+	order := map[Stage]int{StageCrawl: 0, StageParse: 1, StageGenerate: 2, StageValidate: 3}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Stage != issues[j].Stage {
+			return order[issues[i].Stage] < order[issues[j].Stage]
+		}
+		return issues[i].File < issues[j].File
+	})
+	return issues
+}
+
+// WriteSummary writes a human readable summary of the run and every issue
+func (r *Report) WriteSummary(w io.Writer) error {
+	issues := r.sortedIssues()
+	for _, issue := range issues {
+		if _, err := fmt.Fprintln(w, issue.String()); err != nil {
+			return err
+		}
+		if issue.Snippet != "" {
+			if _, err := fmt.Fprintln(w, issue.Snippet); err != nil {
+				return err
+			}
+		}
+	}
+	status := "succeeded"
+	if len(issues) > 0 {
+		status = "failed"
+	}
+	_, err := fmt.Fprintf(w, "datasourcerer %s: %d test template(s), %d data source(s), %d test file(s) generated, %d error(s)\n", status, r.Templates, r.DataSources, r.Generated, len(issues))
+	return err
+}
+
+// WriteJSON writes the report as JSON to the file at path
+func (r *Report) WriteJSON(path string) error {
+	content, err := json.MarshalIndent(struct {
+		Success     bool    `json:"success"`
+		Templates   int     `json:"templates"`
+		DataSources int     `json:"dataSources"`
+		Generated   int     `json:"generated"`
+		Issues      []Issue `json:"issues"`
+	}{
+		Success:     !r.Failed(),
+		Templates:   r.Templates,
+		DataSources: r.DataSources,
+		Generated:   r.Generated,
+		Issues:      append([]Issue{}, r.sortedIssues()...),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing report: %w", err)
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing report file '%s': %w", path, err)
+	}
+	return nil
+}
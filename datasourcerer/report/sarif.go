@@ -0,0 +1,111 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// The subset of SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html) needed to annotate data sources
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	EndColumn   int           `json:"endColumn,omitempty"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+// WriteSarif writes the issues as a SARIF log, so editors and CI can annotate the offending data source cells
+func (r *Report) WriteSarif(w io.Writer, version string) error {
+	results := []sarifResult{}
+	for _, issue := range r.sortedIssues() {
+		location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(issue.File)}}
+		if issue.Line > 0 {
+			location.Region = &sarifRegion{StartLine: issue.Line, StartColumn: issue.Column, EndColumn: issue.EndColumn}
+			if issue.Snippet != "" {
+				location.Region.Snippet = &sarifMessage{Text: issue.Snippet}
+			}
+		}
+
+		properties := map[string]interface{}{}
+		if issue.Template != "" {
+			properties["template"] = issue.Template
+		}
+		if issue.ColumnIndex > 0 {
+			properties["columnIndex"] = issue.ColumnIndex
+		}
+		if issue.ColumnName != "" {
+			properties["columnName"] = issue.ColumnName
+		}
+		if issue.Value != "" {
+			properties["value"] = issue.Value
+		}
+
+		results = append(results, sarifResult{
+			RuleID:     fmt.Sprintf("datasourcerer/%s", issue.Stage),
+			Level:      "error",
+			Message:    sarifMessage{Text: issue.Message},
+			Locations:  []sarifLocation{{PhysicalLocation: location}},
+			Properties: properties,
+		})
+	}
+
+	content, err := json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "datasourcerer", Version: version}},
+			Results: results,
+		}},
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error serializing diagnostics: %w", err)
+	}
+	_, err = w.Write(append(content, '\n'))
+	return err
+}
@@ -0,0 +1,60 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Snippet returns the line of the content with the field starting at the 1-based byte column underlined by carets:
+//
+//	3 | 2,Jane,<MALFORMED>
+//	  |        ^^^^^^^^^^^
+//
+// The underline is width bytes wide, at least one caret. An empty string is returned if the line does not exist
+func Snippet(content []byte, line int, column int, width int) string {
+	if line < 1 {
+		return ""
+	}
+	lines := bytes.Split(content, []byte("\n"))
+	if line > len(lines) {
+		return ""
+	}
+	text := strings.TrimRight(string(lines[line-1]), "\r")
+	gutter := fmt.Sprintf("%d | ", line)
+	blank := strings.Repeat(" ", len(gutter)-2) + "| "
+	if column < 1 || column > len(text)+1 {
+		return gutter + text
+	}
+
+	start := column - 1
+	end := start + width
+	if end > len(text) {
+		end = len(text)
+	}
+	carets := utf8.RuneCountInString(text[start:end])
+	if carets < 1 {
+		carets = 1
+	}
+	// Keep tabs so the carets line up with the offending value
+	padding := strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+		return ' '
+	}, text[:start])
+	return gutter + text + "\n" + blank + padding + strings.Repeat("^", carets)
+}
+
+// FieldWidth returns the width in bytes of a CSV field as written in the data source, quotes included
+func FieldWidth(content []byte, line int, column int, value string) int {
+	lines := bytes.Split(content, []byte("\n"))
+	if line < 1 || line > len(lines) || column < 1 || column > len(lines[line-1]) {
+		return len(value)
+	}
+	if lines[line-1][column-1] == '"' {
+		return len(value) + strings.Count(value, `"`) + 2
+	}
+	return len(value)
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Snippet(t *testing.T) {
+	content := []byte("Id,Name\n1,John\n2,\"Jane\",<MALFORMED>\n\tx,y")
+
+	tests := []struct {
+		name     string
+		line     int
+		column   int
+		width    int
+		expected string
+	}{
+		{name: "value", line: 3, column: 10, width: 11, expected: "3 | 2,\"Jane\",<MALFORMED>\n  |          ^^^^^^^^^^^"},
+		{name: "quoted value", line: 3, column: 3, width: 6, expected: "3 | 2,\"Jane\",<MALFORMED>\n  |   ^^^^^^"},
+		{name: "tab", line: 4, column: 2, width: 1, expected: "4 | \tx,y\n  | \t^"},
+		{name: "empty value", line: 2, column: 7, width: 0, expected: "2 | 1,John\n  |       ^"},
+		{name: "unknown column", line: 2, column: 0, width: 0, expected: "2 | 1,John"},
+		{name: "unknown line", line: 0, column: 1, width: 1, expected: ""},
+		{name: "line out of range", line: 5, column: 1, width: 1, expected: ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Snippet(content, tt.line, tt.column, tt.width))
+		})
+	}
+}
+
+func Test_FieldWidth(t *testing.T) {
+	content := []byte("Id,Name\n1,\"Jo \"\"hn\"\"\"")
+	assert.Equal(t, 1, FieldWidth(content, 2, 1, "1"))
+	assert.Equal(t, 11, FieldWidth(content, 2, 3, `Jo "hn"`))
+	assert.Equal(t, 4, FieldWidth(content, 0, 0, "John"))
+}
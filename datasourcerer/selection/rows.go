@@ -0,0 +1,61 @@
+package selection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Range is an inclusive range of 1-based row numbers
+type Range struct {
+	From int
+	To   int
+}
+
+// Ranges are the rows selected by a rows specification such as '1-5,9'
+type Ranges []Range
+
+// Contains reports whether the row number is in one of the ranges
+func (r Ranges) Contains(row int) bool {
+	for _, rng := range r {
+		if row >= rng.From && row <= rng.To {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRows parses a comma separated list of row numbers and ranges, e.g. '1-5,9'. Row numbers are 1-based and do not count the header
+func ParseRows(spec string) (Ranges, error) {
+	var ranges Ranges
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid rows '%s': empty row number", spec)
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := parseRowNumber(spec, from)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseRowNumber(spec, to); err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid rows '%s': range '%s' ends before it starts", spec, part)
+			}
+		}
+		ranges = append(ranges, Range{From: first, To: last})
+	}
+	return ranges, nil
+}
+
+func parseRowNumber(spec string, value string) (int, error) {
+	row, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || row < 1 {
+		return 0, fmt.Errorf("invalid rows '%s': '%s' is not a row number, row numbers start at 1", spec, strings.TrimSpace(value))
+	}
+	return row, nil
+}
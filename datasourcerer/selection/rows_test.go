@@ -0,0 +1,35 @@
+package selection_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/selection"
+)
+
+func Test_ParseRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected selection.Ranges
+		err      string
+	}{
+		{name: "single", spec: "3", expected: selection.Ranges{{From: 3, To: 3}}},
+		{name: "ranges", spec: "1-5, 9", expected: selection.Ranges{{From: 1, To: 5}, {From: 9, To: 9}}},
+		{name: "zero", spec: "0-2", err: "invalid rows '0-2': '0' is not a row number, row numbers start at 1"},
+		{name: "reversed", spec: "5-1", err: "invalid rows '5-1': range '5-1' ends before it starts"},
+		{name: "empty", spec: "1,,2", err: "invalid rows '1,,2': empty row number"},
+		{name: "text", spec: "first", err: "invalid rows 'first': 'first' is not a row number, row numbers start at 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, err := selection.ParseRows(tt.spec)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, ranges)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package selection
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
+
+// Selection picks a subset of the rows and columns of a data source when a mock is rendered
+type Selection struct {
+	Rows    Ranges   // The 1-based data rows to keep, in data source order. Nil keeps every row
+	Where   Expr     // The condition the rows must match. Nil keeps every row
+	Columns []string // The columns to keep, in the listed order. Nil keeps every column
+}
+
+// Apply returns a table with the rows selected by Rows and Where, and the columns listed in Columns.
+// Rows are selected first, so that the row numbers refer to the data source rather than to the rows matching the condition
+func (s *Selection) Apply(table *formatter.Table) (*formatter.Table, error) {
+	result := &formatter.Table{Columns: table.Columns}
+	for i, row := range table.Rows {
+		if s.Rows != nil && !s.Rows.Contains(i+1) {
+			continue
+		}
+		if s.Where != nil {
+			match, err := s.Where.Eval(rowValues{columns: table.Columns, row: row})
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating 'where' for the row in line %d: %w", row.Line, err)
+			}
+			if !match {
+				continue
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if s.Columns == nil {
+		return result, nil
+	}
+	indexes := make([]int, len(s.Columns))
+	projected := &formatter.Table{Columns: make([]formatter.Column, len(s.Columns))}
+	for i, name := range s.Columns {
+		index := columnIndex(table.Columns, name)
+		if index < 0 {
+			return nil, fmt.Errorf("unknown column '%s' in 'columns', expected one of '%s'", name, strings.Join(columnNames(table.Columns), "', '"))
+		}
+		indexes[i] = index
+		projected.Columns[i] = table.Columns[index]
+	}
+	for _, row := range result.Rows {
+		cells := make([]formatter.Cell, 0, len(indexes))
+		for _, index := range indexes {
+			if index < len(row.Cells) {
+				cells = append(cells, row.Cells[index])
+			}
+		}
+		projected.Rows = append(projected.Rows, formatter.Row{Line: row.Line, Source: row.Source, Cells: cells})
+	}
+	return projected, nil
+}
+
+// columnIndex returns the index of the column with the name, compared case-insensitively, or -1
+func columnIndex(columns []formatter.Column, name string) int {
+	for i, column := range columns {
+		if strings.EqualFold(column.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func columnNames(columns []formatter.Column) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// rowValues resolves the columns referenced by a condition to the values of a row
+type rowValues struct {
+	columns []formatter.Column
+	row     formatter.Row
+}
+
+func (r rowValues) Lookup(name string) (string, string, bool, error) {
+	index := columnIndex(r.columns, name)
+	if index < 0 {
+		return "", "", false, fmt.Errorf("unknown column '%s', expected one of '%s'", name, strings.Join(columnNames(r.columns), "', '"))
+	}
+	if index >= len(r.row.Cells) {
+		// A row with fewer values than the table has columns lacks the trailing values
+		return "", r.columns[index].Type, true, nil
+	}
+	cell := r.row.Cells[index]
+	return cell.Value, r.columns[index].Type, cell.Null, nil
+}
@@ -0,0 +1,148 @@
+package selection_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/selection"
+)
+
+// orders is a table with the columns ID NUMBER(10,0), STATUS VARCHAR, AMOUNT NUMBER(10,2), OPEN BOOLEAN and CREATED DATE
+func orders() *formatter.Table {
+	records := [][]string{
+		{"1", "open", "9.50", "true", "2024-01-15"},
+		{"2", "closed", "100.00", "false", "2024-02-01"},
+		{"3", "open", "25.00", "true", "2023-12-31"},
+		{"4", "o'neil", "100", "false", "2024-03-10"},
+	}
+	table := &formatter.Table{Columns: []formatter.Column{
+		{Name: "ID", Type: "NUMBER(10,0)"},
+		{Name: "STATUS", Type: "VARCHAR(16777216)"},
+		{Name: "AMOUNT", Type: "NUMBER(10,2)"},
+		{Name: "OPEN", Type: "BOOLEAN"},
+		{Name: "CREATED", Type: "DATE"},
+	}}
+	for i, record := range records {
+		row := formatter.Row{Line: i + 2}
+		for _, value := range record {
+			row.Cells = append(row.Cells, formatter.Cell{Value: value, Sql: []byte(value)})
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+func ids(table *formatter.Table) []string {
+	ids := []string{}
+	for _, row := range table.Rows {
+		ids = append(ids, row.Cells[0].Value)
+	}
+	return ids
+}
+
+func Test_Selection_Where(t *testing.T) {
+	tests := []struct {
+		where    string
+		expected []string
+		err      string
+	}{
+		{where: "status = 'open'", expected: []string{"1", "3"}},
+		{where: "STATUS <> 'open'", expected: []string{"2", "4"}},
+		{where: "amount >= 25", expected: []string{"2", "3", "4"}},
+		{where: "amount = '100.0'", expected: []string{"2", "4"}},
+		{where: "amount > 9 and amount < 100", expected: []string{"1", "3"}},
+		{where: "open = true or id = 2", expected: []string{"1", "2", "3"}},
+		{where: "not (open = false) and created < '2024-01-01'", expected: []string{"3"}},
+		{where: "id in (1, 4)", expected: []string{"1", "4"}},
+		{where: "id not in (1, 4)", expected: []string{"2", "3"}},
+		{where: "status like 'o%'", expected: []string{"1", "3", "4"}},
+		{where: "status = 'o''neil'", expected: []string{"4"}},
+		{where: `"status" not like '_pen'`, expected: []string{"2", "4"}},
+		{where: "amount > 'many'", err: "error evaluating 'where' for the row in line 2: cannot compare column 'amount' of type 'NUMBER(10,2)' with 'many'"},
+		{where: "name = 'x'", err: "error evaluating 'where' for the row in line 2: unknown column 'name', expected one of 'ID', 'STATUS', 'AMOUNT', 'OPEN', 'CREATED'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			where, err := selection.ParseWhere(tt.where)
+			assert.Nil(t, err)
+			table, err := (&selection.Selection{Where: where}).Apply(orders())
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, ids(table))
+		})
+	}
+}
+
+func Test_Selection_Where_Null(t *testing.T) {
+	// The amount of order 2 and the status of order 3 are NULL, the status of order 4 is an empty string
+	table := orders()
+	table.Rows[1].Cells[2] = formatter.Cell{Null: true, Sql: []byte("NULL")}
+	table.Rows[2].Cells[1] = formatter.Cell{Null: true, Sql: []byte("NULL")}
+	table.Rows[3].Cells[1] = formatter.Cell{Value: "", Sql: []byte("''")}
+
+	tests := []struct {
+		where    string
+		expected []string
+	}{
+		{where: "amount > 5", expected: []string{"1", "3", "4"}},
+		{where: "amount is null", expected: []string{"2"}},
+		{where: "amount IS NOT NULL", expected: []string{"1", "3", "4"}},
+		{where: "status = ''", expected: []string{"4"}},
+		{where: "status is null or status in ('open')", expected: []string{"1", "3"}},
+		{where: "status like '%'", expected: []string{"1", "2", "4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			where, err := selection.ParseWhere(tt.where)
+			assert.Nil(t, err)
+			selected, err := (&selection.Selection{Where: where}).Apply(table)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, ids(selected))
+		})
+	}
+}
+
+func Test_ParseWhere_Errors(t *testing.T) {
+	tests := []struct {
+		where string
+		err   string
+	}{
+		{where: "status = 'open", err: "invalid where 'status = 'open': unterminated '"},
+		{where: "status 'open'", err: "invalid where 'status 'open'': expected a comparison operator after 'status'"},
+		{where: "(status = 'open'", err: "invalid where '(status = 'open'': expected ')'"},
+		{where: "status = 'open' id = 1", err: "invalid where 'status = 'open' id = 1': unexpected 'id'"},
+		{where: "id in 1", err: "invalid where 'id in 1': expected '(' after 'in'"},
+		{where: "id not = 1", err: "invalid where 'id not = 1': expected 'in' or 'like' after 'not'"},
+		{where: "id = status", err: "invalid where 'id = status': expected a value, found 'status'"},
+		{where: "id ! 1", err: "invalid where 'id ! 1': unexpected '!'"},
+		{where: "id is 1", err: "invalid where 'id is 1': expected 'null' or 'not null' after 'is'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			_, err := selection.ParseWhere(tt.where)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func Test_Selection_RowsAndColumns(t *testing.T) {
+	rows, err := selection.ParseRows("1-2,4")
+	assert.Nil(t, err)
+	where, err := selection.ParseWhere("open = false")
+	assert.Nil(t, err)
+
+	table, err := (&selection.Selection{Rows: rows, Where: where, Columns: []string{"status", "id"}}).Apply(orders())
+	assert.Nil(t, err)
+	assert.Equal(t, []formatter.Column{{Name: "STATUS", Type: "VARCHAR(16777216)"}, {Name: "ID", Type: "NUMBER(10,0)"}}, table.Columns)
+	assert.Equal(t, []formatter.Row{
+		{Line: 3, Cells: []formatter.Cell{{Value: "closed", Sql: []byte("closed")}, {Value: "2", Sql: []byte("2")}}},
+		{Line: 5, Cells: []formatter.Cell{{Value: "o'neil", Sql: []byte("o'neil")}, {Value: "4", Sql: []byte("4")}}},
+	}, table.Rows)
+
+	_, err = (&selection.Selection{Columns: []string{"id", "name"}}).Apply(orders())
+	assert.EqualError(t, err, "unknown column 'name' in 'columns', expected one of 'ID', 'STATUS', 'AMOUNT', 'OPEN', 'CREATED'")
+}
@@ -0,0 +1,447 @@
+package selection
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Values resolves a column name to the normalized value of the column in a row, the sql type of the column and whether the value is NULL
+type Values interface {
+	Lookup(name string) (value string, typ string, null bool, err error)
+}
+
+// Expr is a parsed 'where' condition, e.g. "status = 'open' and amount >= 100"
+type Expr interface {
+	Eval(values Values) (bool, error)
+}
+
+type and struct{ left, right Expr }
+
+func (e and) Eval(values Values) (bool, error) {
+	left, err := e.left.Eval(values)
+	if err != nil || !left {
+		return false, err
+	}
+	return e.right.Eval(values)
+}
+
+type or struct{ left, right Expr }
+
+func (e or) Eval(values Values) (bool, error) {
+	left, err := e.left.Eval(values)
+	if err != nil || left {
+		return left, err
+	}
+	return e.right.Eval(values)
+}
+
+type not struct{ expr Expr }
+
+func (e not) Eval(values Values) (bool, error) {
+	match, err := e.expr.Eval(values)
+	return !match, err
+}
+
+type literal struct {
+	text string
+}
+
+// isNull checks whether the value of a column is NULL: 'is null' and 'is not null'
+type isNull struct {
+	column string
+}
+
+func (e isNull) Eval(values Values) (bool, error) {
+	_, _, null, err := values.Lookup(e.column)
+	return null, err
+}
+
+// comparison compares a column with literals: '=', '!=', '<', '<=', '>', '>=', 'in' and 'like'. As in sql, a comparison with a NULL value is never true
+type comparison struct {
+	column   string
+	operator string
+	literals []literal
+}
+
+func (e comparison) Eval(values Values) (bool, error) {
+	value, typ, null, err := values.Lookup(e.column)
+	if err != nil || null {
+		return false, err
+	}
+	switch e.operator {
+	case "in":
+		for _, lit := range e.literals {
+			cmp, err := compare(e.column, value, typ, lit)
+			if err != nil {
+				return false, err
+			}
+			if cmp == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "like":
+		return likeRegex(e.literals[0].text).MatchString(value), nil
+	}
+
+	cmp, err := compare(e.column, value, typ, e.literals[0])
+	if err != nil {
+		return false, err
+	}
+	switch e.operator {
+	case "=":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// compare compares the value of a column with a literal as the type of the column: numerically for numbers, as booleans for booleans,
+// and as text otherwise. Dates, times and timestamps are normalized to sortable formats, so they compare correctly as text
+func compare(column string, value string, typ string, lit literal) (int, error) {
+	switch kindOf(typ) {
+	case kindNumber:
+		a, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value '%s' of column '%s' is not a number", value, column)
+		}
+		b, err := strconv.ParseFloat(lit.text, 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot compare column '%s' of type '%s' with '%s'", column, typ, lit.text)
+		}
+		switch {
+		case a < b:
+			return -1, nil
+		case a > b:
+			return 1, nil
+		}
+		return 0, nil
+	case kindBoolean:
+		a, err := strconv.ParseBool(value)
+		if err != nil {
+			return 0, fmt.Errorf("value '%s' of column '%s' is not a boolean", value, column)
+		}
+		b, err := strconv.ParseBool(lit.text)
+		if err != nil {
+			return 0, fmt.Errorf("cannot compare column '%s' of type '%s' with '%s'", column, typ, lit.text)
+		}
+		switch {
+		case a == b:
+			return 0, nil
+		case b:
+			return -1, nil
+		}
+		return 1, nil
+	default:
+		return strings.Compare(value, lit.text), nil
+	}
+}
+
+type kind int
+
+const (
+	kindText kind = iota
+	kindNumber
+	kindBoolean
+)
+
+func kindOf(typ string) kind {
+	typ = strings.ToLower(typ)
+	for _, prefix := range []string{"number", "numeric", "decimal", "int", "bigint", "smallint", "float", "double", "real"} {
+		if strings.HasPrefix(typ, prefix) {
+			return kindNumber
+		}
+	}
+	if strings.HasPrefix(typ, "bool") {
+		return kindBoolean
+	}
+	return kindText
+}
+
+// likeRegex translates a sql 'like' pattern, where '%' matches any text and '_' a single character, to a regular expression
+func likeRegex(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^(?s)")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// ParseWhere parses a condition of comparisons between columns and literals and 'is null' checks combined with 'and', 'or', 'not' and parentheses,
+// e.g. "status = 'open' and (amount > 100 or id in (1, 2)) and closed is null"
+func ParseWhere(src string) (Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid where '%s': %w", src, err)
+	}
+	p := &whereParser{tokens: tokens}
+	expr, err := p.or()
+	if err == nil && !p.done() {
+		err = fmt.Errorf("unexpected '%s'", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid where '%s': %w", src, err)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenIdentifier tokenKind = iota
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenPunctuation
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '\'' || c == '"':
+			// Single quotes delimit strings and double quotes identifiers. A doubled quote escapes the quote
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(src); j++ {
+				if src[j] == c {
+					if j+1 < len(src) && src[j+1] == c {
+						sb.WriteByte(c)
+						j++
+						continue
+					}
+					break
+				}
+				sb.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated %c", c)
+			}
+			kind := tokenString
+			if c == '"' {
+				kind = tokenIdentifier
+			}
+			tokens = append(tokens, token{kind: kind, text: sb.String()})
+			i = j + 1
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{kind: tokenPunctuation, text: string(c)})
+			i++
+		case strings.IndexByte("=!<>", c) >= 0:
+			j := i + 1
+			if j < len(src) && (src[j] == '=' || (c == '<' && src[j] == '>')) {
+				j++
+			}
+			operator := src[i:j]
+			switch operator {
+			case "==":
+				operator = "="
+			case "<>":
+				operator = "!="
+			case "!":
+				return nil, fmt.Errorf("unexpected '!'")
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: operator})
+			i = j
+		case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(src) && strings.IndexByte("0123456789.eE", src[j]) >= 0 {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[i:j]})
+			i = j
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || (src[j] >= 'a' && src[j] <= 'z') || (src[j] >= 'A' && src[j] <= 'Z') || (src[j] >= '0' && src[j] <= '9')) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, text: src[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected '%c'", c)
+		}
+	}
+	return tokens, nil
+}
+
+type whereParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *whereParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *whereParser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+// keyword consumes the next token when it is the keyword, compared case-insensitively
+func (p *whereParser) keyword(keyword string) bool {
+	if t := p.peek(); !p.done() && t.kind == tokenIdentifier && strings.EqualFold(t.text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *whereParser) punctuation(text string) bool {
+	if t := p.peek(); !p.done() && t.kind == tokenPunctuation && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *whereParser) or() (Expr, error) {
+	left, err := p.and()
+	for err == nil && p.keyword("or") {
+		var right Expr
+		if right, err = p.and(); err == nil {
+			left = or{left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *whereParser) and() (Expr, error) {
+	left, err := p.not()
+	for err == nil && p.keyword("and") {
+		var right Expr
+		if right, err = p.not(); err == nil {
+			left = and{left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *whereParser) not() (Expr, error) {
+	if p.keyword("not") {
+		expr, err := p.not()
+		return not{expr: expr}, err
+	}
+	if p.punctuation("(") {
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.punctuation(")") {
+			return nil, fmt.Errorf("expected ')'")
+		}
+		return expr, nil
+	}
+	return p.comparison()
+}
+
+func (p *whereParser) comparison() (Expr, error) {
+	column := p.peek()
+	if p.done() || column.kind != tokenIdentifier {
+		return nil, fmt.Errorf("expected a column name")
+	}
+	p.pos++
+
+	if p.keyword("is") {
+		negate := p.keyword("not")
+		if !p.keyword("null") {
+			return nil, fmt.Errorf("expected 'null' or 'not null' after 'is'")
+		}
+		return negated(isNull{column: column.text}, negate), nil
+	}
+
+	negate := p.keyword("not")
+	switch {
+	case p.keyword("in"):
+		if !p.punctuation("(") {
+			return nil, fmt.Errorf("expected '(' after 'in'")
+		}
+		var literals []literal
+		for {
+			lit, err := p.literal()
+			if err != nil {
+				return nil, err
+			}
+			literals = append(literals, lit)
+			if p.punctuation(")") {
+				break
+			}
+			if !p.punctuation(",") {
+				return nil, fmt.Errorf("expected ',' or ')' in the 'in' list")
+			}
+		}
+		return negated(comparison{column: column.text, operator: "in", literals: literals}, negate), nil
+	case p.keyword("like"):
+		lit, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		return negated(comparison{column: column.text, operator: "like", literals: []literal{lit}}, negate), nil
+	case negate:
+		return nil, fmt.Errorf("expected 'in' or 'like' after 'not'")
+	}
+
+	operator := p.peek()
+	if p.done() || operator.kind != tokenOperator {
+		return nil, fmt.Errorf("expected a comparison operator after '%s'", column.text)
+	}
+	p.pos++
+	lit, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+	return comparison{column: column.text, operator: operator.text, literals: []literal{lit}}, nil
+}
+
+func negated(expr Expr, negate bool) Expr {
+	if negate {
+		return not{expr: expr}
+	}
+	return expr
+}
+
+func (p *whereParser) literal() (literal, error) {
+	t := p.peek()
+	switch {
+	case p.done():
+		return literal{}, fmt.Errorf("expected a value")
+	case t.kind == tokenString:
+		p.pos++
+		return literal{text: t.text}, nil
+	case t.kind == tokenNumber:
+		p.pos++
+		return literal{text: t.text}, nil
+	case t.kind == tokenIdentifier && (strings.EqualFold(t.text, "true") || strings.EqualFold(t.text, "false")):
+		p.pos++
+		return literal{text: strings.ToLower(t.text)}, nil
+	}
+	return literal{}, fmt.Errorf("expected a value, found '%s'", t.text)
+}
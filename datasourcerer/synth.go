@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/postgres"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake"
+	"github.com/tsanton/dbt-unit-test-fusionizer/synth"
+)
+
+// runSynth generates a typed csv data source from a schema: a sidecar YAML file with generators or a csv file with an annotated header.
+// The output is read back with the csv reader of the dialect, so that a schema with an invalid type fails here and not in the tests
+func runSynth(args []string) int {
+	flags := flag.NewFlagSet("synth", flag.ContinueOnError)
+	schemaPath := flags.String("schema", "", "The schema to generate from: a .yaml/.yml file with rows, seed and columns, or a .csv file with an annotated header")
+	rows := flags.Int("rows", 100, "The number of rows to generate. Overrides the rows of a YAML schema")
+	seed := flags.Int64("seed", 0, "The seed of the generated values. Overrides the seed of a YAML schema")
+	out := flags.String("out", "", "The csv file to write. Defaults to stdout")
+	separator := flags.String("separator", ",", "The field delimiter of the csv")
+	dialect := flags.String("dialect", "snowflake", "The dialect the output is validated against: 'snowflake' or 'postgres'")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *schemaPath == "" {
+		logger.Error("the 'schema' flag is required")
+		return 2
+	}
+	if len([]rune(*separator)) != 1 {
+		logger.Error(fmt.Sprintf("the separator must be a single character, got '%s'", *separator))
+		return 2
+	}
+	sep := []rune(*separator)[0]
+
+	content, err := os.ReadFile(*schemaPath)
+	if err != nil {
+		logger.Error(fmt.Sprintf("error reading schema: %s", err.Error()))
+		return 1
+	}
+	var schema *synth.Schema
+	switch strings.ToLower(filepath.Ext(*schemaPath)) {
+	case ".yaml", ".yml":
+		schema, err = synth.ParseYamlSchema(content)
+	default:
+		schema, err = synth.ParseHeaderSchema(content, sep, '#')
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("error reading schema '%s': %s", *schemaPath, err.Error()))
+		return 1
+	}
+
+	// The flags override the schema when set, and a schema without rows gets the default of the flag
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["rows"] || schema.Rows == 0 {
+		schema.Rows = *rows
+	}
+	if set["seed"] {
+		schema.Seed = *seed
+	}
+
+	var buf bytes.Buffer
+	if err := synth.Generate(&buf, schema, sep); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	if err := validateSynth(buf.Bytes(), *dialect, *separator); err != nil {
+		logger.Error(fmt.Sprintf("generated data source is not valid: %s", err.Error()))
+		return 1
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(*out, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("error writing data source: %s", err.Error()))
+		return 1
+	}
+	return 0
+}
+
+// validateSynth reads the generated csv with the csv reader of the dialect
+func validateSynth(content []byte, dialect string, separator string) error {
+	f, err := csvFormatter(dialect, separator)
+	if err != nil {
+		return err
+	}
+	return f.Read(bytes.NewReader(content))
+}
+
+// csvFormatter returns the formatter of the dialect for a csv data source with the separator
+func csvFormatter(dialect string, separator string) (formatter.IDataSourceFormatter, error) {
+	csv := formatter.NewDefaultCsvConfig()
+	csv.Separator = separator
+	config := &formatter.Config{Dialect: dialect, Filetype: formatter.ParserInputTypeCsv, CSV: csv}
+	switch dialect {
+	case "snowflake":
+		return snowflake.Constructor()(logger, config), nil
+	case "postgres":
+		return postgres.Constructor()(logger, config), nil
+	default:
+		return nil, fmt.Errorf("dialect type '%s' not supported", dialect)
+	}
+}
@@ -0,0 +1,66 @@
+package synth
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	firstNames = []string{"Anna", "Ben", "Clara", "David", "Emma", "Finn", "Grace", "Henry", "Ida", "Jonas", "Kari", "Lucas", "Maja", "Noah", "Olivia", "Peter", "Rosa", "Sofie", "Thomas", "Vera"}
+	lastNames  = []string{"Andersen", "Berg", "Carlsen", "Dahl", "Eriksen", "Fischer", "Garcia", "Hansen", "Jensen", "Johnson", "Larsen", "Miller", "Nilsen", "Olsen", "Pedersen", "Smith", "Taylor", "Wilson"}
+	companies  = []string{"Acme", "Globex", "Initech", "Umbrella", "Stark", "Wayne", "Hooli", "Vandelay", "Cyberdyne", "Soylent"}
+	suffixes   = []string{"Inc", "Ltd", "AS", "GmbH", "LLC"}
+	cities     = []string{"Oslo", "Bergen", "Stockholm", "Copenhagen", "Berlin", "London", "Paris", "Madrid", "Amsterdam", "New York", "Chicago", "Toronto"}
+	countries  = []string{"Norway", "Sweden", "Denmark", "Finland", "Germany", "France", "Spain", "Netherlands", "United Kingdom", "United States", "Canada"}
+	words      = []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliett", "kilo", "lima", "mike", "november", "oscar", "papa", "quebec", "romeo", "sierra", "tango"}
+	domains    = []string{"example.com", "example.org", "example.net"}
+)
+
+// fakerGenerator returns a generator of fake values of the kind, drawn from built-in lists so that the output only depends on the seed
+func fakerGenerator(kind string) (generator, error) {
+	pick := func(random *rand.Rand, values []string) string {
+		return values[random.Intn(len(values))]
+	}
+	switch kind {
+	case "first_name":
+		return func(random *rand.Rand, _ int) string { return pick(random, firstNames) }, nil
+	case "last_name":
+		return func(random *rand.Rand, _ int) string { return pick(random, lastNames) }, nil
+	case "name":
+		return func(random *rand.Rand, _ int) string {
+			return pick(random, firstNames) + " " + pick(random, lastNames)
+		}, nil
+	case "email":
+		return func(random *rand.Rand, _ int) string {
+			return fmt.Sprintf("%s.%s%d@%s", strings.ToLower(pick(random, firstNames)), strings.ToLower(pick(random, lastNames)), random.Intn(100), pick(random, domains))
+		}, nil
+	case "company":
+		return func(random *rand.Rand, _ int) string {
+			return pick(random, companies) + " " + pick(random, suffixes)
+		}, nil
+	case "city":
+		return func(random *rand.Rand, _ int) string { return pick(random, cities) }, nil
+	case "country":
+		return func(random *rand.Rand, _ int) string { return pick(random, countries) }, nil
+	case "word":
+		return func(random *rand.Rand, _ int) string { return pick(random, words) }, nil
+	case "phone":
+		return func(random *rand.Rand, _ int) string {
+			return fmt.Sprintf("+47 %03d %02d %03d", random.Intn(1000), random.Intn(100), random.Intn(1000))
+		}, nil
+	case "uuid":
+		return func(random *rand.Rand, _ int) string {
+			id, err := uuid.NewRandomFromReader(random)
+			if err != nil {
+				// A math/rand source never fails to read
+				panic(err)
+			}
+			return id.String()
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown faker '%s', expected one of email, first_name, last_name, name, company, city, country, word, phone or uuid", kind)
+	}
+}
@@ -0,0 +1,334 @@
+package synth
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/date"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake/reader/csvreader/timestamp/utils"
+)
+
+// generator returns the value of a column in the row (0-based), drawing from the seeded random source
+type generator func(random *rand.Rand, row int) string
+
+const (
+	dateLayout      = "2006-01-02"
+	timestampLayout = "2006-01-02 15:04:05"
+	timeLayout      = "15:04:05"
+)
+
+// typeRegex matches the type annotation of a header, e.g. 'Age[number(3,0)]' or the portable 'Age[int]'
+var typeRegex = regexp.MustCompile(`\[(\w+)(?:\((.*?)\))?\]\s*$`)
+
+// parseGenerator parses a generator spec: 'faker:<kind>', 'range:<min>..<max>' of integers, decimals, dates or timestamps,
+// 'choice:[a,b,...]', 'seq:<start>' of integers, dates or timestamps, or 'time'
+func parseGenerator(spec string) (generator, error) {
+	kind, args, _ := strings.Cut(spec, ":")
+	args = strings.TrimSpace(args)
+	switch strings.TrimSpace(kind) {
+	case "faker":
+		return fakerGenerator(args)
+	case "range":
+		return rangeGenerator(args)
+	case "choice":
+		values := strings.Split(strings.TrimSuffix(strings.TrimPrefix(args, "["), "]"), ",")
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		if len(values) == 1 && values[0] == "" {
+			return nil, fmt.Errorf("expected 'choice:[<value>,...]' with at least one value")
+		}
+		return func(random *rand.Rand, _ int) string {
+			return values[random.Intn(len(values))]
+		}, nil
+	case "seq":
+		return seqGenerator(args)
+	case "time":
+		return func(random *rand.Rand, _ int) string {
+			return time.Date(2000, 1, 1, 0, 0, random.Intn(24*60*60), 0, time.UTC).Format(timeLayout)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown generator '%s', expected 'faker', 'range', 'choice', 'seq' or 'time'", kind)
+	}
+}
+
+// seqGenerator returns a generator of the sequence from the start, 1 by default: integers, or dates by the day or timestamps by the second
+func seqGenerator(args string) (generator, error) {
+	if args == "" {
+		args = "1"
+	}
+	if start, err := strconv.ParseInt(args, 10, 64); err == nil {
+		return func(_ *rand.Rand, row int) string {
+			return strconv.FormatInt(start+int64(row), 10)
+		}, nil
+	}
+	if start, err := time.Parse(dateLayout, args); err == nil {
+		return func(_ *rand.Rand, row int) string {
+			return start.AddDate(0, 0, row).Format(dateLayout)
+		}, nil
+	}
+	if start, err := time.Parse(timestampLayout, args); err == nil {
+		return func(_ *rand.Rand, row int) string {
+			return start.Add(time.Duration(row) * time.Second).Format(timestampLayout)
+		}, nil
+	}
+	return nil, fmt.Errorf("expected 'seq:<start>' with an integer, date ('%s') or timestamp ('%s') start", dateLayout, timestampLayout)
+}
+
+// rangeGenerator returns a generator of uniformly distributed values between the bounds '<min>..<max>', both included.
+// The bounds are integers, decimals (with the scale of the bound with the most decimals), dates or timestamps
+func rangeGenerator(args string) (generator, error) {
+	lower, upper, ok := strings.Cut(args, "..")
+	lower, upper = strings.TrimSpace(lower), strings.TrimSpace(upper)
+	if !ok || lower == "" || upper == "" {
+		return nil, fmt.Errorf("expected 'range:<min>..<max>'")
+	}
+
+	min, minErr := strconv.ParseInt(lower, 10, 64)
+	max, maxErr := strconv.ParseInt(upper, 10, 64)
+	if minErr == nil && maxErr == nil {
+		if max < min {
+			return nil, fmt.Errorf("range '%s' must end with an integer not less than %d", args, min)
+		}
+		return func(random *rand.Rand, _ int) string {
+			return strconv.FormatInt(min+random.Int63n(max-min+1), 10)
+		}, nil
+	}
+	if min, err := strconv.ParseFloat(lower, 64); err == nil {
+		max, err := strconv.ParseFloat(upper, 64)
+		if err != nil || max < min {
+			return nil, fmt.Errorf("range '%s' must end with a number not less than %s", args, lower)
+		}
+		scale := decimals(lower)
+		if s := decimals(upper); s > scale {
+			scale = s
+		}
+		return func(random *rand.Rand, _ int) string {
+			return strconv.FormatFloat(min+random.Float64()*(max-min), 'f', scale, 64)
+		}, nil
+	}
+	for _, layout := range []string{dateLayout, timestampLayout} {
+		min, err := time.Parse(layout, lower)
+		if err != nil {
+			continue
+		}
+		max, err := time.Parse(layout, upper)
+		if err != nil || max.Before(min) {
+			return nil, fmt.Errorf("range '%s' must end with a %s not before %s", args, map[string]string{dateLayout: "date", timestampLayout: "timestamp"}[layout], lower)
+		}
+		step := time.Second
+		if layout == dateLayout {
+			step = 24 * time.Hour
+		}
+		steps := int64(max.Sub(min) / step)
+		layout := layout
+		return func(random *rand.Rand, _ int) string {
+			return min.Add(time.Duration(random.Int63n(steps+1)) * step).Format(layout)
+		}, nil
+	}
+	return nil, fmt.Errorf("range '%s' must be of integers, decimals, dates ('%s') or timestamps ('%s')", args, dateLayout, timestampLayout)
+}
+
+// decimals returns the number of digits after the decimal point of a number
+func decimals(number string) int {
+	if _, fraction, ok := strings.Cut(number, "."); ok {
+		return len(fraction)
+	}
+	return 0
+}
+
+// constraints are the constraints of an annotated header the default generators keep to, e.g. 'unique' and 'min=1' of 'Id[number(38,0)|unique|min=1]'
+type constraints struct {
+	notNull bool
+	unique  bool
+	enum    string
+	min     string
+	max     string
+}
+
+// parseConstraints returns the constraints of an annotated header. Invalid constraints are ignored here and reported by the csv reader of the dialect
+func parseConstraints(header string) constraints {
+	_, list := formatter.SplitConstraints(header)
+	var c constraints
+	for _, constraint := range list {
+		name, value, _ := strings.Cut(strings.TrimSpace(constraint), "=")
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "not_null":
+			c.notNull = true
+		case "unique":
+			c.unique = true
+		case "enum":
+			c.enum = value
+		case "min":
+			c.min = value
+		case "max":
+			c.max = value
+		}
+	}
+	return c
+}
+
+// defaultGenerator returns the generator of a column without one, from the type annotation of its header: numbers in a range,
+// dates and timestamps of the last years, the representations of a boolean, the values of an enum and words for text.
+// The numbers, dates and timestamps are kept within the min and max of the column, and a unique column gets a sequence starting at its min
+func defaultGenerator(header string) string {
+	c := parseConstraints(header)
+	if c.enum != "" {
+		// The values of an enum column are drawn from its enum
+		return "choice:[" + c.enum + "]"
+	}
+	typed, _ := formatter.SplitConstraints(header)
+	match := typeRegex.FindStringSubmatch(typed)
+	if match == nil {
+		return textSpec(c)
+	}
+	args := strings.Split(match[2], ",")
+	switch typ := strings.ToLower(match[1]); {
+	case typ == "number" || typ == "numeric" || typ == "decimal":
+		scale := 0
+		if len(args) == 2 {
+			scale, _ = strconv.Atoi(strings.TrimSpace(args[1]))
+		}
+		return numberSpec(c, scale)
+	case typ == "integer" || typ == "int" || typ == "bigint" || typ == "smallint":
+		return numberSpec(c, 0)
+	case typ == "date":
+		return timeSpec(c, layoutOf(typ, args), dateLayout, 24*time.Hour, "2020-01-01", "2024-12-31")
+	case strings.HasPrefix(typ, "timestamp") || typ == "datetime":
+		return timeSpec(c, layoutOf(typ, args), timestampLayout, time.Second, "2020-01-01 00:00:00", "2024-12-31 23:59:59")
+	case strings.HasPrefix(typ, "time"):
+		return "time"
+	case typ == "boolean" || typ == "bool":
+		representations := []string{"true", "false"}
+		for i, arg := range args {
+			if i < 2 && strings.TrimSpace(arg) != "" {
+				representations[i] = strings.TrimSpace(arg)
+			}
+		}
+		return "choice:[" + strings.Join(representations, ",") + "]"
+	case typ == "jsonb" || typ == "variant" || typ == "json":
+		return "choice:[{}]"
+	default:
+		return textSpec(c)
+	}
+}
+
+// textSpec returns the spec of words, or of a sequence of numbers for a unique column
+func textSpec(c constraints) string {
+	if c.unique {
+		return "seq:1"
+	}
+	return "faker:word"
+}
+
+// numberSpec returns the spec of numbers with the scale between the min and max of the column, 1 (0 with a scale) to 1000 by default.
+// A bound beyond the default range moves the other one along, and a unique column gets a sequence of integers starting at its min
+func numberSpec(c constraints, scale int) string {
+	lower, upper := 1.0, 1000.0
+	if scale > 0 {
+		lower = 0
+	}
+	span := upper - lower
+	min, minErr := strconv.ParseFloat(c.min, 64)
+	max, maxErr := strconv.ParseFloat(c.max, 64)
+	if minErr == nil {
+		lower = min
+		if maxErr != nil && upper < lower {
+			upper = lower + span
+		}
+	}
+	if maxErr == nil {
+		upper = max
+		if minErr != nil && upper < lower {
+			lower = upper - span
+		}
+	}
+	if c.unique {
+		return fmt.Sprintf("seq:%d", int64(math.Ceil(lower)))
+	}
+	// The bounds are rounded into the range, a min of 0.5 is 1 for a scale of 0
+	factor := math.Pow10(scale)
+	lower, upper = math.Ceil(lower*factor)/factor, math.Floor(upper*factor)/factor
+	return "range:" + strconv.FormatFloat(lower, 'f', scale, 64) + ".." + strconv.FormatFloat(upper, 'f', scale, 64)
+}
+
+// timeSpec returns the spec of dates or timestamps between the min and max of the column, written in the layout of its annotation, and from lower to upper by default.
+// A bound beyond the default range moves the other one along, and a unique column gets a sequence of steps starting at its min
+func timeSpec(c constraints, layout string, defaultLayout string, step time.Duration, lower string, upper string) string {
+	from, _ := time.Parse(defaultLayout, lower)
+	to, _ := time.Parse(defaultLayout, upper)
+	span := to.Sub(from)
+	min, minErr := time.Parse(layout, c.min)
+	max, maxErr := time.Parse(layout, c.max)
+	if minErr == nil {
+		from = min.UTC()
+		if maxErr != nil && to.Before(from) {
+			to = from.Add(span)
+		}
+	}
+	if maxErr == nil {
+		to = max.UTC()
+		if minErr != nil && to.Before(from) {
+			from = to.Add(-span)
+		}
+	}
+	// The bounds are rounded into the range, to whole days of dates and whole seconds of timestamps
+	if truncated := from.Truncate(step); truncated.Before(from) {
+		from = truncated.Add(step)
+	}
+	to = to.Truncate(step)
+	if c.unique {
+		return "seq:" + from.Format(defaultLayout)
+	}
+	return "range:" + from.Format(defaultLayout) + ".." + to.Format(defaultLayout)
+}
+
+// layoutOf returns the go layout of the format of a date or timestamp annotation, e.g. '02/01/2006' for 'date(dd/MM/yyyy)', and the default layout of the type without a format
+func layoutOf(typ string, args []string) string {
+	format := ""
+	if len(args) > 0 {
+		format = strings.TrimSpace(args[0])
+	}
+	mapper, layout := utils.TimestampFormatMapper, timestampLayout
+	if typ == "date" {
+		mapper, layout = date.DateFormatMapper, dateLayout
+	}
+	if format == "" {
+		return layout
+	}
+	if mapped, ok := mapper[format]; ok {
+		return mapped
+	}
+	return format
+}
+
+// formatted returns the generator with its dates and timestamps written in the format of the type annotation of the header, e.g. '31/01/2024' for 'Due[date(dd/MM/yyyy)]'.
+// The generator is returned as it is for other columns, and values that are not a date or timestamp are kept as they are
+func formatted(g generator, header string) generator {
+	typed, _ := formatter.SplitConstraints(header)
+	match := typeRegex.FindStringSubmatch(typed)
+	if match == nil {
+		return g
+	}
+	typ := strings.ToLower(match[1])
+	if typ != "date" && !strings.HasPrefix(typ, "timestamp") && typ != "datetime" {
+		return g
+	}
+	layout := layoutOf(typ, strings.Split(match[2], ","))
+	return func(random *rand.Rand, row int) string {
+		value := g(random, row)
+		for _, from := range []string{dateLayout, timestampLayout} {
+			if t, err := time.Parse(from, value); err == nil {
+				return t.Format(layout)
+			}
+		}
+		return value
+	}
+}
@@ -0,0 +1,118 @@
+package synth
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"gopkg.in/yaml.v3"
+)
+
+// Column is a column of a synthetic data source: its annotated header, e.g. 'Age[number(3,0)]', and how its values are generated.
+// A column without a generator gets the default generator of its type and constraints
+type Column struct {
+	Name      string  `yaml:"name"`
+	Generator string  `yaml:"generator"` //e.g. 'faker:email', 'range:1..100', 'choice:[a,b]' or 'seq:1000'
+	NullRate  float64 `yaml:"null_rate"` //The share of NULL values, written as '\N', 0 by default
+}
+
+// Schema describes a synthetic data source, read from a sidecar YAML file or from an annotated csv header
+type Schema struct {
+	Rows    int      `yaml:"rows"`
+	Seed    int64    `yaml:"seed"`
+	Columns []Column `yaml:"columns"`
+}
+
+// ParseYamlSchema parses a sidecar YAML schema with the rows, seed and columns of the data source
+func ParseYamlSchema(content []byte) (*Schema, error) {
+	schema := &Schema{}
+	if err := yaml.Unmarshal(content, schema); err != nil {
+		return nil, fmt.Errorf("error parsing schema: %w", err)
+	}
+	if len(schema.Columns) == 0 {
+		return nil, fmt.Errorf("schema has no columns")
+	}
+	return schema, nil
+}
+
+// ParseHeaderSchema parses the annotated header of a csv file, the first line that is not empty or a comment, as a schema with the default generator of every column
+func ParseHeaderSchema(content []byte, separator rune, comment rune) (*Schema, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, string(comment)) {
+			continue
+		}
+		r := csv.NewReader(strings.NewReader(line))
+		r.Comma = separator
+		r.TrimLeadingSpace = true
+		names, err := r.Read()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing header: %w", err)
+		}
+		schema := &Schema{}
+		for _, name := range names {
+			schema.Columns = append(schema.Columns, Column{Name: name})
+		}
+		return schema, nil
+	}
+	return nil, fmt.Errorf("schema has no header")
+}
+
+// Generate writes the rows of the schema as csv with the annotated header, drawing every value from a generator seeded with the seed of the schema
+func Generate(w io.Writer, schema *Schema, separator rune) error {
+	if schema.Rows < 0 {
+		return fmt.Errorf("rows must not be negative, got %d", schema.Rows)
+	}
+	generators := make([]generator, len(schema.Columns))
+	names := make([]string, len(schema.Columns))
+	for i, column := range schema.Columns {
+		if column.NullRate < 0 || column.NullRate > 1 {
+			return fmt.Errorf("null_rate of column '%s' must be between 0 and 1, got %v", column.Name, column.NullRate)
+		}
+		if column.NullRate > 0 && parseConstraints(column.Name).notNull {
+			return fmt.Errorf("null_rate of column '%s' must be 0, the column is not_null", column.Name)
+		}
+		spec := column.Generator
+		if spec == "" {
+			spec = defaultGenerator(column.Name)
+		}
+		g, err := parseGenerator(spec)
+		if err != nil {
+			return fmt.Errorf("invalid generator '%s' of column '%s': %w", spec, column.Name, err)
+		}
+		generators[i] = formatted(g, column.Name)
+		names[i] = column.Name
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = separator
+	if err := cw.Write(names); err != nil {
+		return fmt.Errorf("error writing csv: %w", err)
+	}
+	random := rand.New(rand.NewSource(schema.Seed))
+	record := make([]string, len(schema.Columns))
+	for row := 0; row < schema.Rows; row++ {
+		for i, column := range schema.Columns {
+			// Draw the value also for NULL values, so that the null rate of a column does not change the values of the other columns
+			value := generators[i](random, row)
+			if column.NullRate > 0 && random.Float64() < column.NullRate {
+				value = formatter.NullValue
+			}
+			record[i] = value
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("error writing csv: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("error writing csv: %w", err)
+	}
+	return nil
+}
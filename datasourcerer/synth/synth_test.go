@@ -0,0 +1,191 @@
+package synth_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/postgres"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake"
+	"github.com/tsanton/dbt-unit-test-fusionizer/synth"
+)
+
+var logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+func generate(t *testing.T, schema *synth.Schema) [][]string {
+	var buf bytes.Buffer
+	err := synth.Generate(&buf, schema, ',')
+	assert.Nil(t, err)
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.Nil(t, err)
+	return records
+}
+
+func Test_Generate_Generators(t *testing.T) {
+	tests := []struct {
+		name      string
+		column    synth.Column
+		predicate func(value string) bool
+	}{
+		{name: "email", column: synth.Column{Name: "Email", Generator: "faker:email"}, predicate: regexp.MustCompile(`^[a-z]+\.[a-z]+\d+@example\.(com|org|net)$`).MatchString},
+		{name: "uuid", column: synth.Column{Name: "Id", Generator: "faker:uuid"}, predicate: regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString},
+		{name: "integer range", column: synth.Column{Name: "Age[number(3,0)]", Generator: "range:1..100"}, predicate: func(value string) bool {
+			n, err := strconv.Atoi(value)
+			return err == nil && n >= 1 && n <= 100
+		}},
+		{name: "decimal range", column: synth.Column{Name: "Amount[number(10,2)]", Generator: "range:0.00..10.00"}, predicate: regexp.MustCompile(`^(\d|10)\.\d{2}$`).MatchString},
+		{name: "date range", column: synth.Column{Name: "Birthday[date()]", Generator: "range:2024-01-01..2024-01-31"}, predicate: regexp.MustCompile(`^2024-01-([0-2]\d|3[01])$`).MatchString},
+		{name: "formatted date range", column: synth.Column{Name: "Birthday[date(dd/MM/yyyy)]", Generator: "range:2024-01-01..2024-01-31"}, predicate: regexp.MustCompile(`^([0-2]\d|3[01])/01/2024$`).MatchString},
+		{name: "choice", column: synth.Column{Name: "Status", Generator: "choice:[active, inactive]"}, predicate: regexp.MustCompile(`^(active|inactive)$`).MatchString},
+		{name: "default number", column: synth.Column{Name: "Price[number(10,2)]"}, predicate: regexp.MustCompile(`^\d+\.\d{2}$`).MatchString},
+		{name: "default boolean", column: synth.Column{Name: "Active[boolean(Y,N)]"}, predicate: regexp.MustCompile(`^(Y|N)$`).MatchString},
+		{name: "default portable", column: synth.Column{Name: "Amount[decimal(10,2)]"}, predicate: regexp.MustCompile(`^\d+\.\d{2}$`).MatchString},
+		{name: "default enum", column: synth.Column{Name: "Status[varchar(10)|enum=open,closed]"}, predicate: regexp.MustCompile(`^(open|closed)$`).MatchString},
+		{name: "default text", column: synth.Column{Name: "Name"}, predicate: regexp.MustCompile(`^[a-z]+$`).MatchString},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := generate(t, &synth.Schema{Rows: 50, Seed: 1, Columns: []synth.Column{tt.column}})
+			assert.Equal(t, []string{tt.column.Name}, records[0])
+			assert.Len(t, records, 51)
+			for _, record := range records[1:] {
+				assert.True(t, tt.predicate(record[0]), "unexpected value '%s'", record[0])
+			}
+		})
+	}
+}
+
+// Test_Generate_Valid synthesizes from annotated headers and reads the output back with the csv reader of the dialect
+func Test_Generate_Valid(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		header  string
+	}{
+		{name: "snowflake formats", dialect: "snowflake", header: `Due[date(dd/MM/yyyy)],Paid[date(02.01.2006)],"Seen[timestamp_ntz(MM/dd/yyyy HH:mm:ss,3)]",Created[timestamp_tz(yyyy-MM-ddTHH:mm:ssZ)],Name`},
+		{name: "postgres formats", dialect: "postgres", header: `Due[date(dd-MM-yyyy)],Seen[timestamp(yyyy/MM/dd HH:mm:ss)],Name`},
+		{name: "unique", dialect: "snowflake", header: `"Id[number(38,0)|unique]",Name[varchar(10)|unique|not_null],Due[date()|unique],Seen[timestamp_ntz()|unique]`},
+		{name: "bounds", dialect: "snowflake", header: `"Qty[number(9,0)|min=5000]","Price[number(10,2)|min=0.5|max=0.75]","Due[date(dd/MM/yyyy)|min=01/01/2030]","Seen[timestamp_ntz()|max=1999-12-31 00:00:00]"`},
+		{name: "unique bounds", dialect: "postgres", header: `"Id[int()|unique|min=100|max=199]","Due[date(dd-MM-yyyy)|unique|min=15-02-2024]"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := synth.ParseHeaderSchema([]byte(tt.header), ',', '#')
+			assert.Nil(t, err)
+			schema.Rows = 100
+			var buf bytes.Buffer
+			assert.Nil(t, synth.Generate(&buf, schema, ','))
+
+			config := &formatter.Config{Dialect: tt.dialect, Filetype: formatter.ParserInputTypeCsv, CSV: formatter.NewDefaultCsvConfig()}
+			var f formatter.IDataSourceFormatter
+			if tt.dialect == "snowflake" {
+				f = snowflake.Constructor()(logger, config)
+			} else {
+				f = postgres.Constructor()(logger, config)
+			}
+			assert.Nil(t, f.Read(&buf))
+			assert.Len(t, f.Table().Rows, 100)
+		})
+	}
+}
+
+func Test_Generate_Sequence(t *testing.T) {
+	records := generate(t, &synth.Schema{Rows: 3, Columns: []synth.Column{{Name: "Id[number(10,0)]", Generator: "seq:1000"}}})
+	assert.Equal(t, [][]string{{"Id[number(10,0)]"}, {"1000"}, {"1001"}, {"1002"}}, records)
+
+	records = generate(t, &synth.Schema{Rows: 2, Columns: []synth.Column{{Name: "Due[date(dd/MM/yyyy)]", Generator: "seq:2024-02-28"}, {Name: "Id[int()|unique|min=7]"}}})
+	assert.Equal(t, [][]string{{"Due[date(dd/MM/yyyy)]", "Id[int()|unique|min=7]"}, {"28/02/2024", "7"}, {"29/02/2024", "8"}}, records)
+}
+
+func Test_Generate_Reproducible(t *testing.T) {
+	schema := &synth.Schema{Rows: 20, Seed: 42, Columns: []synth.Column{
+		{Name: "Name", Generator: "faker:name"},
+		{Name: "Age[number(3,0)]", Generator: "range:18..99", NullRate: 0.5},
+	}}
+	first := generate(t, schema)
+	assert.Equal(t, first, generate(t, schema))
+
+	schema.Seed = 43
+	assert.NotEqual(t, first, generate(t, schema))
+}
+
+func Test_Generate_NullRate(t *testing.T) {
+	records := generate(t, &synth.Schema{Rows: 1000, Seed: 1, Columns: []synth.Column{{Name: "Id", Generator: "seq"}, {Name: "Age[number(3,0)]", NullRate: 0.1}}})
+	nulls := 0
+	for _, record := range records[1:] {
+		if record[1] == formatter.NullValue {
+			nulls++
+		}
+	}
+	assert.InDelta(t, 100, nulls, 40)
+}
+
+// Test_Generate_NullRate_ReadBack reads the NULL values of typed and text columns back as NULL, not as empty strings
+func Test_Generate_NullRate_ReadBack(t *testing.T) {
+	schema := &synth.Schema{Rows: 20, Seed: 1, Columns: []synth.Column{
+		{Name: "Age[number(3,0)]", NullRate: 1},
+		{Name: "Name", NullRate: 1},
+		{Name: "Email[varchar(320)]", Generator: "faker:email", NullRate: 1},
+	}}
+	var buf bytes.Buffer
+	assert.Nil(t, synth.Generate(&buf, schema, ','))
+
+	f := snowflake.Constructor()(logger, &formatter.Config{Dialect: "snowflake", Filetype: formatter.ParserInputTypeCsv, CSV: formatter.NewDefaultCsvConfig()})
+	assert.Nil(t, f.Read(&buf))
+	assert.Len(t, f.Table().Rows, 20)
+	for _, row := range f.Table().Rows {
+		for _, cell := range row.Cells {
+			assert.True(t, cell.Null)
+		}
+	}
+	assert.Contains(t, string(f.Table().Sql()), "SELECT NULL::NUMBER(3,0) AS AGE, NULL::VARCHAR(16777216) AS NAME, NULL::VARCHAR(320) AS EMAIL\n")
+}
+
+func Test_Generate_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		column synth.Column
+		err    string
+	}{
+		{name: "unknown generator", column: synth.Column{Name: "Name", Generator: "lorem"}, err: "invalid generator 'lorem' of column 'Name': unknown generator 'lorem', expected 'faker', 'range', 'choice', 'seq' or 'time'"},
+		{name: "unknown faker", column: synth.Column{Name: "Name", Generator: "faker:pet"}, err: "invalid generator 'faker:pet' of column 'Name': unknown faker 'pet', expected one of email, first_name, last_name, name, company, city, country, word, phone or uuid"},
+		{name: "reversed range", column: synth.Column{Name: "Age", Generator: "range:10..1"}, err: "invalid generator 'range:10..1' of column 'Age': range '10..1' must end with an integer not less than 10"},
+		{name: "null rate", column: synth.Column{Name: "Age", NullRate: 1.5}, err: "null_rate of column 'Age' must be between 0 and 1, got 1.5"},
+		{name: "not null", column: synth.Column{Name: "Age[number(3,0)|not_null]", NullRate: 0.1}, err: "null_rate of column 'Age[number(3,0)|not_null]' must be 0, the column is not_null"},
+		{name: "sequence", column: synth.Column{Name: "Id", Generator: "seq:a"}, err: "invalid generator 'seq:a' of column 'Id': expected 'seq:<start>' with an integer, date ('2006-01-02') or timestamp ('2006-01-02 15:04:05') start"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := synth.Generate(&bytes.Buffer{}, &synth.Schema{Rows: 1, Columns: []synth.Column{tt.column}}, ',')
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func Test_ParseSchema(t *testing.T) {
+	schema, err := synth.ParseYamlSchema([]byte(`
+rows: 10
+seed: 7
+columns:
+  - name: Email
+    generator: faker:email
+  - name: Age[number(3,0)]
+    generator: range:1..100
+    null_rate: 0.1
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, &synth.Schema{Rows: 10, Seed: 7, Columns: []synth.Column{
+		{Name: "Email", Generator: "faker:email"},
+		{Name: "Age[number(3,0)]", Generator: "range:1..100", NullRate: 0.1},
+	}}, schema)
+
+	schema, err = synth.ParseHeaderSchema([]byte("# users\n\"Id[number(10,0)]\", Name\n1,John\n"), ',', '#')
+	assert.Nil(t, err)
+	assert.Equal(t, &synth.Schema{Columns: []synth.Column{{Name: "Id[number(10,0)]"}, {Name: "Name"}}}, schema)
+}
@@ -0,0 +1,95 @@
+package templatecrawler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
+
+// Adapter describes the macros of a dbt mocking package: which call blocks mock a model or source, and which option references the data source
+type Adapter struct {
+	MockMacros    []string // Macros whose call blocks receive the content of their data source
+	ExpectMacros  []string // Macros whose call blocks are the expected output of a test. They receive the content of their data source like the mocks
+	TestMacros    []string // Macros whose first argument is the tested model, used to select cases by model name
+	SourceFileKey string   // The option key of a mock call referencing its data source
+}
+
+// adapters are the built-in profiles of the supported dbt mocking packages
+var adapters = map[string]Adapter{
+	// EqualExperts dbt-unit-testing (https://github.com/EqualExperts/dbt-unit-testing), called through the package name
+	"dbt_unit_testing": {
+		MockMacros:    []string{"dbt_unit_testing.mock_ref", "dbt_unit_testing.mock_source"},
+		ExpectMacros:  []string{"dbt_unit_testing.expect"},
+		TestMacros:    []string{"dbt_unit_testing.test"},
+		SourceFileKey: "source_file",
+	},
+	// EqualExperts dbt-unit-testing with its macros dispatched into the project namespace, e.g. '{% call mock_ref('model') %}'
+	"dbt_unit_testing_unqualified": {
+		MockMacros:    []string{"mock_ref", "mock_source"},
+		ExpectMacros:  []string{"expect"},
+		TestMacros:    []string{"test"},
+		SourceFileKey: "source_file",
+	},
+	// No built-in macros, only the macros listed in the config
+	"none": {
+		SourceFileKey: "source_file",
+	},
+}
+
+// DefaultAdapter returns the adapter for the dbt_unit_testing package
+func DefaultAdapter() Adapter {
+	return adapters["dbt_unit_testing"]
+}
+
+// NewAdapter returns the built-in adapter profile of the config, extended with the configured macros and source file key
+func NewAdapter(config formatter.MocksConfig) (Adapter, error) {
+	name := config.Adapter
+	if name == "" {
+		name = "dbt_unit_testing"
+	}
+	profile, ok := adapters[name]
+	if !ok {
+		return Adapter{}, fmt.Errorf("unknown mocks adapter '%s', expected one of '%s'", config.Adapter, strings.Join(AdapterNames(), "', '"))
+	}
+
+	adapter := Adapter{
+		MockMacros:    extend(profile.MockMacros, config.Macros),
+		ExpectMacros:  extend(profile.ExpectMacros, config.ExpectMacros),
+		TestMacros:    extend(profile.TestMacros, config.TestMacros),
+		SourceFileKey: profile.SourceFileKey,
+	}
+	if config.SourceFileKey != "" {
+		adapter.SourceFileKey = config.SourceFileKey
+	}
+	if len(adapter.MockMacros) == 0 && len(adapter.ExpectMacros) == 0 {
+		return Adapter{}, fmt.Errorf("mocks adapter '%s' requires at least one macro in 'macros' or 'expectMacros'", name)
+	}
+	return adapter, nil
+}
+
+// extend returns the macros of the profile followed by the configured macros, without duplicates
+func extend(profile []string, configured []string) []string {
+	if len(profile) == 0 && len(configured) == 0 {
+		return nil
+	}
+	return lo.Uniq(append(append([]string{}, profile...), configured...))
+}
+
+// AdapterNames returns the names of the built-in adapter profiles
+func AdapterNames() []string {
+	names := lo.Keys(adapters)
+	sort.Strings(names)
+	return names
+}
+
+// isMockMacro reports whether the call blocks of the macro receive the content of their data source: the mocks and the expectations
+func (a Adapter) isMockMacro(macro string) bool {
+	return lo.Contains(a.MockMacros, macro) || lo.Contains(a.ExpectMacros, macro)
+}
+
+func (a Adapter) isTestMacro(macro string) bool {
+	return lo.Contains(a.TestMacros, macro)
+}
@@ -0,0 +1,91 @@
+package templatecrawler_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+)
+
+func Test_NewAdapter(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   formatter.MocksConfig
+		expected templatecrawler.Adapter
+		err      string
+	}{
+		{
+			name:     "default",
+			config:   formatter.MocksConfig{},
+			expected: templatecrawler.DefaultAdapter(),
+		},
+		{
+			name:   "unqualified",
+			config: formatter.MocksConfig{Adapter: "dbt_unit_testing_unqualified"},
+			expected: templatecrawler.Adapter{
+				MockMacros:    []string{"mock_ref", "mock_source"},
+				ExpectMacros:  []string{"expect"},
+				TestMacros:    []string{"test"},
+				SourceFileKey: "source_file",
+			},
+		},
+		{
+			name:   "extended",
+			config: formatter.MocksConfig{Macros: []string{"my_package.mock_ref", "dbt_unit_testing.mock_ref"}, SourceFileKey: "fixture"},
+			expected: templatecrawler.Adapter{
+				MockMacros:    []string{"dbt_unit_testing.mock_ref", "dbt_unit_testing.mock_source", "my_package.mock_ref"},
+				ExpectMacros:  []string{"dbt_unit_testing.expect"},
+				TestMacros:    []string{"dbt_unit_testing.test"},
+				SourceFileKey: "fixture",
+			},
+		},
+		{
+			name:   "expect and test macros",
+			config: formatter.MocksConfig{ExpectMacros: []string{"my_package.expect"}, TestMacros: []string{"my_package.test"}},
+			expected: templatecrawler.Adapter{
+				MockMacros:    []string{"dbt_unit_testing.mock_ref", "dbt_unit_testing.mock_source"},
+				ExpectMacros:  []string{"dbt_unit_testing.expect", "my_package.expect"},
+				TestMacros:    []string{"dbt_unit_testing.test", "my_package.test"},
+				SourceFileKey: "source_file",
+			},
+		},
+		{
+			name:   "custom package",
+			config: formatter.MocksConfig{Adapter: "none", Macros: []string{"my_package.mock"}, ExpectMacros: []string{"my_package.expect"}, TestMacros: []string{"my_package.test"}},
+			expected: templatecrawler.Adapter{
+				MockMacros:    []string{"my_package.mock"},
+				ExpectMacros:  []string{"my_package.expect"},
+				TestMacros:    []string{"my_package.test"},
+				SourceFileKey: "source_file",
+			},
+		},
+		{
+			name:     "custom macros only",
+			config:   formatter.MocksConfig{Adapter: "none", Macros: []string{"my_package.mock"}},
+			expected: templatecrawler.Adapter{MockMacros: []string{"my_package.mock"}, SourceFileKey: "source_file"},
+		},
+		{
+			name:   "no macros",
+			config: formatter.MocksConfig{Adapter: "none"},
+			err:    "mocks adapter 'none' requires at least one macro in 'macros' or 'expectMacros'",
+		},
+		{
+			name:   "unknown",
+			config: formatter.MocksConfig{Adapter: "dbt_mock"},
+			err:    "unknown mocks adapter 'dbt_mock', expected one of 'dbt_unit_testing', 'dbt_unit_testing_unqualified', 'none'",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, err := templatecrawler.NewAdapter(tt.config)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, adapter)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package templatecrawler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler/jinja"
+)
+
+// matchesCasePath reports whether the template file at path is selected by a case pattern on its file name or path.
+// Patterns are matched against the file name (with or without the .sql extension), the path relative to the template directory and the absolute path, either literally or as a glob.
+func (s *TemplateCrawler) matchesCasePath(path string) bool {
+	base := filepath.Base(path)
+	rel, err := filepath.Rel(s.initDir, path)
+	if err != nil {
+		rel = base
+	}
+	candidates := []string{
+		base,
+		strings.TrimSuffix(base, filepath.Ext(base)),
+		rel,
+		strings.TrimSuffix(rel, filepath.Ext(rel)),
+		path,
+	}
+
+	matched := false
+	for _, pattern := range s.cases {
+		if casePatternMatches(pattern, candidates) {
+			s.markCaseMatched(pattern)
+			matched = true
+		} else if abs, err := filepath.Abs(pattern); err == nil && abs == path {
+			s.markCaseMatched(pattern)
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchesCaseModel reports whether the template file at path tests a model selected by a case pattern.
+// Only the model names are extracted, so the file is not fully processed unless it is selected.
+func (s *TemplateCrawler) matchesCaseModel(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	template, err := jinja.Parse(content)
+	if err != nil {
+		return false, fmt.Errorf("error parsing template: %w", err)
+	}
+	var models []string
+	template.Walk(func(call *jinja.CallBlock) {
+		if !s.adapter.isTestMacro(call.Macro) || len(call.Args) == 0 {
+			return
+		}
+		if model, ok := call.Args[0].(string); ok {
+			models = append(models, model)
+		}
+	})
+	if len(models) == 0 {
+		return false, nil
+	}
+
+	matched := false
+	for _, pattern := range s.cases {
+		if casePatternMatches(pattern, models) {
+			s.markCaseMatched(pattern)
+			matched = true
+		}
+	}
+	return matched, nil
+}
+
+func (s *TemplateCrawler) markCaseMatched(pattern string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matchedCases[pattern] = true
+}
+
+// unmatchedCases returns the case patterns that did not select any test template
+func (s *TemplateCrawler) unmatchedCases() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var unmatched []string
+	for _, pattern := range s.cases {
+		if !s.matchedCases[pattern] {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	return unmatched
+}
+
+func casePatternMatches(pattern string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if strings.EqualFold(pattern, candidate) {
+			return true
+		}
+		if ok, err := filepath.Match(pattern, candidate); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package templatecrawler_test
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+	"github.com/tsanton/dbt-unit-test-fusionizer/testutils"
+)
+
+func crawlCases(t *testing.T, rootDir string, cases ...string) []string {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	c := make(chan templatecrawler.DataSourceReference)
+	crawler := templatecrawler.NewTestTemplateCrawler(logger, 2, rootDir).WithCases(cases)
+	go crawler.Crawl(c)
+	for range c {
+	}
+	var names []string
+	for _, tf := range *crawler.GetTestTemplates() {
+		names = append(names, filepath.Base(tf.AbsFilePath()))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Test_Crawl_Cases(t *testing.T) {
+	ds, out := testutils.BootstrapDirs()
+	defer testutils.CleanupDir(ds, out)
+
+	templates := map[string]string{
+		ds.D1: "test_orders.sql",
+		ds.D2: "test_customers.sql",
+	}
+	for dir, name := range templates {
+		content := "{% call dbt_unit_testing.test('" + name[5:len(name)-4] + "_model', 'a test') %}\n{% endcall %}\n"
+		_, err := testutils.CreateFile(dir, name, content, nil)
+		assert.Nil(t, err)
+	}
+	_, err := testutils.CreateFile(ds.D2, "test_payments.sql", "select 1\n", nil)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name     string
+		cases    []string
+		expected []string
+	}{
+		{"NoCases", nil, []string{"test_customers.sql", "test_orders.sql", "test_payments.sql"}},
+		{"FileName", []string{"test_orders.sql"}, []string{"test_orders.sql"}},
+		{"FileNameWithoutExtension", []string{"test_payments"}, []string{"test_payments.sql"}},
+		{"Glob", []string{"test_*s.sql"}, []string{"test_customers.sql", "test_orders.sql", "test_payments.sql"}},
+		{"RelativePathGlob", []string{filepath.Base(ds.D1) + "/*/test_c*"}, []string{"test_customers.sql"}},
+		{"ModelName", []string{"customers_model"}, []string{"test_customers.sql"}},
+		{"ModelGlob", []string{"*_model"}, []string{"test_customers.sql", "test_orders.sql"}},
+		{"Multiple", []string{"test_orders", "customers_model"}, []string{"test_customers.sql", "test_orders.sql"}},
+		{"NoMatch", []string{"does_not_exist"}, nil},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, crawlCases(t, ds.RootDir, tt.cases...))
+		})
+	}
+}
@@ -7,21 +7,38 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
+	"github.com/tsanton/dbt-unit-test-fusionizer/selection"
 )
 
 type DataSourceReference struct {
 	TestDefintionFilePath string
-	DataSourceFilePath    string
+	DataSourceFilePaths   []string                  // The data source files of the mock, in order. Their rows are concatenated into one mock. The 'vars' of the call are appended as a '?name=value' query
+	InputFormat           formatter.ParserInputType // The 'input_format' of the mock call: the data source is inserted as sql or as normalized csv
+	ColumnSeparator       rune                      // The 'column_separator' of csv input
+	Selection             *selection.Selection      // The rows and columns selected by the 'rows', 'where' and 'columns' options. Nil selects the whole data source
+	InlineContent         []byte                    // The csv written in the body of a mock call with 'inline_format' csv. Nil for data source files
+	Mock                  string                    // The mocked model or source: the names of the mock call joined with '.', e.g. 'customers' or 'raw.customers'
+	TestLine              int                       // The line of the test call the mock is nested in, 0 for a mock outside a test
 	CallLine              int
 	EndCallLine           int
+	BodyOffset            int // Byte offset of the body of the mock call in the test template. An inline data source replaces the body
+	EndCallOffset         int // Byte offset of the '{% endcall %}' tag in the test template, where the data source is inserted
 }
 
 type TemplateCrawler struct {
 	logger               *slog.Logger
 	workers              int
 	initDir              string
+	cases                []string
+	adapter              Adapter
+	matchedCases         map[string]bool
 	dataSourceReferences []chan string
 	dbtFiles             []TestTemplateFile
+	issues               report.Collector
+	mu                   sync.Mutex
 }
 
 func NewTestTemplateCrawler(logger *slog.Logger, workers int, initDir string) *TemplateCrawler {
@@ -29,9 +46,24 @@ func NewTestTemplateCrawler(logger *slog.Logger, workers int, initDir string) *T
 		logger:  logger,
 		workers: workers,
 		initDir: initDir,
+		adapter: DefaultAdapter(),
+		mu:      sync.Mutex{},
 	}
 }
 
+// WithCases limits the crawl to the test templates selected by the case patterns (file names, globs or model names).
+// An empty list selects every test template.
+func (s *TemplateCrawler) WithCases(cases []string) *TemplateCrawler {
+	s.cases = cases
+	return s
+}
+
+// WithAdapter sets the macros recognised as mocks and test cases. Defaults to the dbt_unit_testing adapter
+func (s *TemplateCrawler) WithAdapter(adapter Adapter) *TemplateCrawler {
+	s.adapter = adapter
+	return s
+}
+
 func (s *TemplateCrawler) DataSourceReferences() *[]chan string {
 	return &s.dataSourceReferences
 }
@@ -40,12 +72,19 @@ func (s *TemplateCrawler) GetTestTemplates() *[]TestTemplateFile {
 	return &s.dbtFiles
 }
 
+// Issues returns the errors raised while crawling the test templates
+func (s *TemplateCrawler) Issues() []report.Issue {
+	return s.issues.Issues()
+}
+
 type dbtFileJob struct {
-	path string
+	path       string
+	matchModel bool
 }
 
 func (s *TemplateCrawler) Crawl(c chan<- DataSourceReference) {
 	s.dbtFiles = []TestTemplateFile{}
+	s.matchedCases = map[string]bool{}
 
 	jobs := make(chan dbtFileJob)
 	var wg sync.WaitGroup
@@ -54,10 +93,22 @@ func (s *TemplateCrawler) Crawl(c chan<- DataSourceReference) {
 	for i := 0; i < s.workers; i++ {
 		go func() {
 			for j := range jobs {
+				if j.matchModel {
+					selected, err := s.matchesCaseModel(j.path)
+					if err != nil {
+						s.logger.Error(fmt.Sprintf("error reading test template file '%s': %s", j.path, err.Error()))
+						s.issues.Add(report.Issue{Stage: report.StageCrawl, File: j.path, Message: err.Error()})
+					}
+					if !selected {
+						s.logger.Debug(fmt.Sprintf("test template file '%s' not selected by cases", j.path))
+						wg.Done()
+						continue
+					}
+				}
 				err := s.processTestTemplateFile(j.path, c)
 				if err != nil {
-
 					s.logger.Error(fmt.Sprintf("error processing test template file '%s': %s", j.path, err.Error()))
+					s.issues.Add(report.Issue{Stage: report.StageCrawl, File: j.path, Message: err.Error()})
 				}
 				wg.Done()
 			}
@@ -68,7 +119,6 @@ func (s *TemplateCrawler) Crawl(c chan<- DataSourceReference) {
 	err := filepath.Walk(s.initDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			s.logger.Error(fmt.Sprintf("error accessing a path %q: %v", path, err))
-			close(c)
 			return err
 		}
 
@@ -80,27 +130,39 @@ func (s *TemplateCrawler) Crawl(c chan<- DataSourceReference) {
 		lowerPath := strings.ToLower(info.Name())
 		if strings.HasPrefix(lowerPath, "test_") && strings.HasSuffix(lowerPath, ".sql") {
 			wg.Add(1)
-			jobs <- dbtFileJob{path: path}
+			if len(s.cases) == 0 || s.matchesCasePath(path) {
+				jobs <- dbtFileJob{path: path}
+			} else {
+				jobs <- dbtFileJob{path: path, matchModel: true}
+			}
 		}
 		return nil
 	})
 	if err != nil {
-		s.logger.Error("error walking the path: %v\n", err)
-		close(c)
-		return
+		s.logger.Error(fmt.Sprintf("error walking the path: %v", err))
+		s.issues.Add(report.Issue{Stage: report.StageCrawl, File: s.initDir, Message: err.Error()})
 	}
 
 	wg.Wait()
 	close(jobs) // Close jobs channel after all jobs have been processed
+	for _, pattern := range s.unmatchedCases() {
+		s.logger.Warn(fmt.Sprintf("case '%s' did not match any test template", pattern))
+	}
 	close(c)
 }
 
 func (s *TemplateCrawler) processTestTemplateFile(path string, c chan<- DataSourceReference) error {
 	fileWorker := NewTestTemplateFile(s.logger, s.initDir)
-	fileWorker.ProccessFile(path)
-	for _, dataSource := range *fileWorker.DataSourceReferences() {
-		c <- dataSource
+	fileWorker.adapter = s.adapter
+	err := fileWorker.ProccessFile(path)
+	if err == nil {
+		for _, dataSource := range *fileWorker.DataSourceReferences() {
+			c <- dataSource
+		}
 	}
+	// A failed test template is kept, marked as failed, so its generated test file is not taken for an orphan
+	s.mu.Lock()
 	s.dbtFiles = append(s.dbtFiles, *fileWorker)
-	return nil
+	s.mu.Unlock()
+	return err
 }
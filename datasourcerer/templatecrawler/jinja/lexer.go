@@ -0,0 +1,182 @@
+package jinja
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type TokenKind int
+
+const (
+	TokenText       TokenKind = iota // Template data outside of tags
+	TokenComment                     // {# ... #}
+	TokenStatement                   // {% ... %}
+	TokenExpression                  // {{ ... }}
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenComment:
+		return "comment"
+	case TokenStatement:
+		return "statement"
+	case TokenExpression:
+		return "expression"
+	default:
+		return "text"
+	}
+}
+
+// Token is a piece of a template with its exact byte offsets in the template source
+type Token struct {
+	Kind       TokenKind
+	Start      int    // Byte offset of the first byte of the token
+	End        int    // Byte offset after the last byte of the token
+	Line       int    // 1-based line the token starts on
+	Content    string // The content of a tag without its delimiters, whitespace control and surrounding spaces
+	TrimBefore bool   // The tag opens with '-', e.g. '{%-', and strips the whitespace before it
+	TrimAfter  bool   // The tag closes with '-', e.g. '-%}', and strips the whitespace after it
+}
+
+// SyntaxError is returned when a template cannot be tokenized or parsed
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+var endRawRegex = regexp.MustCompile(`{%[-+]?\s*endraw\s*[-+]?%}`)
+
+// Tokenize splits a template into text, comment, statement and expression tokens.
+// Quoted strings and brackets inside tags are respected, so '%}' or '}}' in a string does not close the tag,
+// and the content of '{% raw %}' blocks is kept as text
+func Tokenize(src []byte) ([]Token, error) {
+	var tokens []Token
+	line := 1
+	pos := 0
+	textStart := 0
+
+	emitText := func(end int) {
+		if end > textStart {
+			tokens = append(tokens, Token{Kind: TokenText, Start: textStart, End: end, Line: line})
+			line += bytes.Count(src[textStart:end], []byte("\n"))
+		}
+	}
+
+	for pos < len(src)-1 {
+		if src[pos] != '{' || (src[pos+1] != '{' && src[pos+1] != '%' && src[pos+1] != '#') {
+			pos++
+			continue
+		}
+		emitText(pos)
+
+		var token Token
+		var err error
+		switch src[pos+1] {
+		case '#':
+			token, err = scanComment(src, pos, line)
+		case '%':
+			token, err = scanTag(src, pos, line, TokenStatement, "%}")
+		default:
+			token, err = scanTag(src, pos, line, TokenExpression, "}}")
+		}
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+		line += bytes.Count(src[token.Start:token.End], []byte("\n"))
+		pos = token.End
+		textStart = pos
+
+		if token.Kind == TokenStatement && token.Content == "raw" {
+			loc := endRawRegex.FindIndex(src[pos:])
+			if loc == nil {
+				return nil, &SyntaxError{Line: token.Line, Message: "'raw' block is never closed"}
+			}
+			emitText(pos + loc[0])
+			endRaw, err := scanTag(src, pos+loc[0], line, TokenStatement, "%}")
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, endRaw)
+			line += bytes.Count(src[endRaw.Start:endRaw.End], []byte("\n"))
+			pos = endRaw.End
+			textStart = pos
+		}
+	}
+	emitText(len(src))
+	return tokens, nil
+}
+
+func scanComment(src []byte, start int, line int) (Token, error) {
+	end := bytes.Index(src[start+2:], []byte("#}"))
+	if end < 0 {
+		return Token{}, &SyntaxError{Line: line, Message: "comment is never closed"}
+	}
+	end += start + 2
+	inner := string(src[start+2 : end])
+	token := Token{Kind: TokenComment, Start: start, End: end + 2, Line: line}
+	if strings.HasPrefix(inner, "-") {
+		token.TrimBefore = true
+		inner = inner[1:]
+	}
+	if strings.HasSuffix(inner, "-") {
+		token.TrimAfter = true
+		inner = inner[:len(inner)-1]
+	}
+	token.Content = strings.TrimSpace(inner)
+	return token, nil
+}
+
+// scanTag scans a statement or expression tag starting at start up to and including its closing delimiter
+func scanTag(src []byte, start int, line int, kind TokenKind, closer string) (Token, error) {
+	token := Token{Kind: kind, Start: start, Line: line}
+	innerStart := start + 2
+	if innerStart < len(src) && (src[innerStart] == '-' || src[innerStart] == '+') {
+		token.TrimBefore = src[innerStart] == '-'
+		innerStart++
+	}
+
+	depth := 0
+	var quote byte
+	for i := innerStart; i < len(src); i++ {
+		c := src[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if depth == 0 && bytes.HasPrefix(src[i:], []byte(closer)) {
+			innerEnd := i
+			if innerEnd > innerStart && (src[innerEnd-1] == '-' || src[innerEnd-1] == '+') {
+				token.TrimAfter = src[innerEnd-1] == '-'
+				innerEnd--
+			}
+			token.Content = strings.TrimSpace(string(src[innerStart:innerEnd]))
+			token.End = i + len(closer)
+			return token, nil
+		}
+		switch c {
+		case '\'', '"':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+	if quote != 0 {
+		return Token{}, &SyntaxError{Line: line, Message: fmt.Sprintf("unterminated string in %s", kind)}
+	}
+	return Token{}, &SyntaxError{Line: line, Message: fmt.Sprintf("%s is never closed, expected '%s'", kind, closer)}
+}
@@ -0,0 +1,76 @@
+package jinja_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler/jinja"
+)
+
+func Test_Tokenize(t *testing.T) {
+	src := "select 1\n{# {% call x() %} #}\n{%- call m('%}', {'a': '}}'}) -%}\n{{ {'b': {'c': 1}} }}{% endcall %}"
+	tokens, err := jinja.Tokenize([]byte(src))
+	assert.Nil(t, err)
+
+	var kinds []jinja.TokenKind
+	end := 0
+	for _, token := range tokens {
+		kinds = append(kinds, token.Kind)
+		assert.Equal(t, end, token.Start) // the tokens cover the whole template
+		end = token.End
+	}
+	assert.Equal(t, []jinja.TokenKind{
+		jinja.TokenText,
+		jinja.TokenComment,
+		jinja.TokenText,
+		jinja.TokenStatement,
+		jinja.TokenText,
+		jinja.TokenExpression,
+		jinja.TokenStatement,
+	}, kinds)
+
+	assert.Equal(t, "{% call x() %}", tokens[1].Content)
+	assert.Equal(t, 2, tokens[1].Line)
+
+	call := tokens[3]
+	assert.Equal(t, "call m('%}', {'a': '}}'})", call.Content)
+	assert.True(t, call.TrimBefore)
+	assert.True(t, call.TrimAfter)
+	assert.Equal(t, 3, call.Line)
+	assert.Equal(t, "{%- call m('%}', {'a': '}}'}) -%}", src[call.Start:call.End])
+
+	assert.Equal(t, "{'b': {'c': 1}}", tokens[5].Content)
+	assert.Equal(t, "endcall", tokens[6].Content)
+	assert.Equal(t, 4, tokens[6].Line)
+	assert.Equal(t, len(src), tokens[6].End)
+}
+
+func Test_Tokenize_Raw(t *testing.T) {
+	src := "{% raw %}{% call x() %}{% endraw %}"
+	tokens, err := jinja.Tokenize([]byte(src))
+	assert.Nil(t, err)
+	assert.Len(t, tokens, 3)
+	assert.Equal(t, jinja.TokenText, tokens[1].Kind)
+	assert.Equal(t, "{% call x() %}", src[tokens[1].Start:tokens[1].End])
+	assert.Equal(t, "endraw", tokens[2].Content)
+}
+
+func Test_Tokenize_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{name: "unclosed statement", src: "\n{% call x() ", expected: "line 2: statement is never closed, expected '%}'"},
+		{name: "unclosed comment", src: "{# comment", expected: "line 1: comment is never closed"},
+		{name: "unterminated string", src: "{{ 'abc }}", expected: "line 1: unterminated string in expression"},
+		{name: "unclosed raw", src: "{% raw %}abc", expected: "line 1: 'raw' block is never closed"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jinja.Tokenize([]byte(tt.src))
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
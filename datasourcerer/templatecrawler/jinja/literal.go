@@ -0,0 +1,313 @@
+package jinja
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expression is a jinja expression that is not a literal, e.g. a variable or a macro call. It is kept as source text
+type Expression struct {
+	Source string
+}
+
+// literalParser parses jinja literals: strings, numbers, booleans, none, lists, tuples and dicts.
+// Strings become string, integers int64, floats float64, lists and tuples []interface{} and dicts map[string]interface{}.
+// Anything else is kept as an Expression
+type literalParser struct {
+	src string
+	pos int
+}
+
+// ParseArguments parses the arguments of a macro call, without the surrounding parentheses, into positional and keyword arguments
+func ParseArguments(src string) ([]interface{}, map[string]interface{}, error) {
+	p := &literalParser{src: src}
+	args := []interface{}{}
+	kwargs := map[string]interface{}{}
+	for {
+		p.skipSpace()
+		if p.done() {
+			return args, kwargs, nil
+		}
+
+		if name, ok := p.keyword(); ok {
+			value, err := p.value(",")
+			if err != nil {
+				return nil, nil, err
+			}
+			kwargs[name] = value
+		} else {
+			value, err := p.value(",")
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, value)
+		}
+
+		p.skipSpace()
+		if p.done() {
+			return args, kwargs, nil
+		}
+		if !p.consume(',') {
+			return nil, nil, fmt.Errorf("expected ',' at position %d in arguments '%s'", p.pos, src)
+		}
+	}
+}
+
+// ParseLiteral parses a single jinja literal
+func ParseLiteral(src string) (interface{}, error) {
+	p := &literalParser{src: src}
+	value, err := p.value("")
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected '%s' after literal", p.src[p.pos:])
+	}
+	return value, nil
+}
+
+func (p *literalParser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *literalParser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *literalParser) consume(c byte) bool {
+	if p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *literalParser) skipSpace() {
+	for !p.done() && strings.ContainsRune(" \t\r\n", rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// keyword consumes 'name=' of a keyword argument
+func (p *literalParser) keyword() (string, bool) {
+	start := p.pos
+	name := p.identifier()
+	p.skipSpace()
+	if name != "" && p.peek() == '=' && !strings.HasPrefix(p.src[p.pos:], "==") {
+		p.pos++
+		return name, true
+	}
+	p.pos = start
+	return "", false
+}
+
+func (p *literalParser) identifier() string {
+	start := p.pos
+	for !p.done() {
+		c := p.src[p.pos]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (p.pos > start && c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// value parses a literal, or an expression when what follows the literal is not one of the terminators or a closing bracket
+func (p *literalParser) value(terminators string) (interface{}, error) {
+	p.skipSpace()
+	start := p.pos
+	value, err := p.literal()
+	if err == nil {
+		p.skipSpace()
+		if p.done() || strings.ContainsRune(terminators+")]}", rune(p.peek())) {
+			return value, nil
+		}
+	}
+	p.pos = start
+	source, err := p.expression(terminators)
+	if err != nil {
+		return nil, err
+	}
+	return Expression{Source: source}, nil
+}
+
+func (p *literalParser) literal() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		return p.string()
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	case c == '[':
+		p.pos++
+		return p.sequence(']')
+	case c == '(':
+		p.pos++
+		return p.sequence(')')
+	case c == '{':
+		p.pos++
+		return p.dict()
+	default:
+		switch p.identifier() {
+		case "true", "True":
+			return true, nil
+		case "false", "False":
+			return false, nil
+		case "none", "None":
+			return nil, nil
+		}
+		return nil, fmt.Errorf("not a literal")
+	}
+}
+
+func (p *literalParser) string() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for !p.done() {
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return sb.String(), nil
+		case c == '\\' && !p.done():
+			escaped := p.src[p.pos]
+			p.pos++
+			switch escaped {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				sb.WriteByte(escaped)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *literalParser) number() (interface{}, error) {
+	start := p.pos
+	if p.peek() == '-' || p.peek() == '+' {
+		p.pos++
+	}
+	for !p.done() && strings.ContainsRune("0123456789._eE", rune(p.peek())) {
+		p.pos++
+	}
+	text := strings.ReplaceAll(p.src[start:p.pos], "_", "")
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i, nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number '%s'", text)
+	}
+	return f, nil
+}
+
+func (p *literalParser) sequence(closer byte) ([]interface{}, error) {
+	values := []interface{}{}
+	for {
+		p.skipSpace()
+		if p.consume(closer) {
+			return values, nil
+		}
+		value, err := p.value(",")
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		p.skipSpace()
+		if p.consume(',') {
+			continue
+		}
+		if !p.consume(closer) {
+			return nil, fmt.Errorf("expected ',' or '%c' at position %d", closer, p.pos)
+		}
+		return values, nil
+	}
+}
+
+func (p *literalParser) dict() (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for {
+		p.skipSpace()
+		if p.consume('}') {
+			return values, nil
+		}
+		key, err := p.value(":")
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(':') {
+			return nil, fmt.Errorf("expected ':' at position %d", p.pos)
+		}
+		value, err := p.value(",")
+		if err != nil {
+			return nil, err
+		}
+		values[fmt.Sprint(key)] = value
+		p.skipSpace()
+		if p.consume(',') {
+			continue
+		}
+		if !p.consume('}') {
+			return nil, fmt.Errorf("expected ',' or '}' at position %d", p.pos)
+		}
+		return values, nil
+	}
+}
+
+// expression consumes the source of an expression up to a terminator or a closing bracket outside of brackets and strings
+func (p *literalParser) expression(terminators string) (string, error) {
+	start := p.pos
+	depth := 0
+	var quote byte
+	for ; !p.done(); p.pos++ {
+		c := p.src[p.pos]
+		if quote != 0 {
+			if c == '\\' {
+				p.pos++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			if depth == 0 {
+				return p.expressionSource(start)
+			}
+			depth--
+		case depth == 0 && strings.IndexByte(terminators, c) >= 0:
+			return p.expressionSource(start)
+		}
+	}
+	if quote != 0 {
+		return "", fmt.Errorf("unterminated string")
+	}
+	return p.expressionSource(start)
+}
+
+func (p *literalParser) expressionSource(start int) (string, error) {
+	source := strings.TrimSpace(p.src[start:p.pos])
+	if source == "" {
+		return "", fmt.Errorf("expected a value at position %d", start)
+	}
+	return source, nil
+}
@@ -0,0 +1,48 @@
+package jinja_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler/jinja"
+)
+
+func Test_ParseLiteral(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected interface{}
+	}{
+		{name: "single quoted string", src: `'a "b" \'c\''`, expected: `a "b" 'c'`},
+		{name: "double quoted string", src: `"a\nb"`, expected: "a\nb"},
+		{name: "integer", src: "-42", expected: int64(-42)},
+		{name: "float", src: "1.5", expected: 1.5},
+		{name: "boolean", src: "True", expected: true},
+		{name: "none", src: "none", expected: nil},
+		{name: "list", src: "['a', 1, [false]]", expected: []interface{}{"a", int64(1), []interface{}{false}}},
+		{name: "tuple", src: "('a',)", expected: []interface{}{"a"}},
+		{name: "dict", src: "{'source_file': 'a.csv', \"rows\": '1-5', 'columns': ['id']}", expected: map[string]interface{}{"source_file": "a.csv", "rows": "1-5", "columns": []interface{}{"id"}}},
+		{name: "expression", src: "var('x') ~ 'y'", expected: jinja.Expression{Source: "var('x') ~ 'y'"}},
+		{name: "dict with expression", src: "{'a': ref('b'), 'c': 1}", expected: map[string]interface{}{"a": jinja.Expression{Source: "ref('b')"}, "c": int64(1)}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := jinja.ParseLiteral(tt.src)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func Test_ParseArguments(t *testing.T) {
+	args, kwargs, err := jinja.ParseArguments(`'model', {'source_file': 'a.csv'}, options={'b': 1}, x == 1`)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"model", map[string]interface{}{"source_file": "a.csv"}, jinja.Expression{Source: "x == 1"}}, args)
+	assert.Equal(t, map[string]interface{}{"options": map[string]interface{}{"b": int64(1)}}, kwargs)
+
+	args, kwargs, err = jinja.ParseArguments("  ")
+	assert.Nil(t, err)
+	assert.Empty(t, args)
+	assert.Empty(t, kwargs)
+}
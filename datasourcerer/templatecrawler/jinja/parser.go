@@ -0,0 +1,159 @@
+package jinja
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CallBlock is a '{% call macro(...) %} ... {% endcall %}' block of a template
+type CallBlock struct {
+	Macro    string                 // Dotted name of the called macro, e.g. 'dbt_unit_testing.mock_ref'
+	Args     []interface{}          // Positional arguments of the macro call
+	Kwargs   map[string]interface{} // Keyword arguments of the macro call
+	Open     Token                  // The '{% call %}' tag
+	Close    Token                  // The '{% endcall %}' tag
+	Children []*CallBlock           // Call blocks nested in the body
+}
+
+// BodyStart returns the byte offset of the first byte after the '{% call %}' tag
+func (c *CallBlock) BodyStart() int {
+	return c.Open.End
+}
+
+// BodyEnd returns the byte offset of the '{% endcall %}' tag
+func (c *CallBlock) BodyEnd() int {
+	return c.Close.Start
+}
+
+// Template is the call block structure of a template
+type Template struct {
+	Source []byte
+	Tokens []Token
+	Calls  []*CallBlock // Top level call blocks in document order
+}
+
+// Walk visits every call block, parents before their children, in document order
+func (t *Template) Walk(fn func(*CallBlock)) {
+	var walk func([]*CallBlock)
+	walk = func(calls []*CallBlock) {
+		for _, call := range calls {
+			fn(call)
+			walk(call.Children)
+		}
+	}
+	walk(t.Calls)
+}
+
+// Parse tokenizes the template and builds the tree of call blocks
+func Parse(src []byte) (*Template, error) {
+	tokens, err := Tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &Template{Source: src, Tokens: tokens}
+	var stack []*CallBlock
+	for _, token := range tokens {
+		if token.Kind != TokenStatement {
+			continue
+		}
+		switch statementName(token.Content) {
+		case "call":
+			call, err := parseCall(token)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, call)
+		case "endcall":
+			if len(stack) == 0 {
+				return nil, &SyntaxError{Line: token.Line, Message: "'endcall' without a matching 'call'"}
+			}
+			call := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			call.Close = token
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, call)
+			} else {
+				template.Calls = append(template.Calls, call)
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return nil, &SyntaxError{Line: stack[len(stack)-1].Open.Line, Message: "'call' block is never closed with 'endcall'"}
+	}
+	return template, nil
+}
+
+func statementName(content string) string {
+	end := strings.IndexFunc(content, func(r rune) bool {
+		return !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	})
+	if end < 0 {
+		return content
+	}
+	return content[:end]
+}
+
+// parseCall parses '{% call[(caller args)] macro(args) %}'
+func parseCall(token Token) (*CallBlock, error) {
+	src := strings.TrimSpace(strings.TrimPrefix(token.Content, "call"))
+
+	// Skip the arguments passed to 'caller()', e.g. '{% call(row) macro() %}'
+	if strings.HasPrefix(src, "(") {
+		end, err := closingParenthesis(src)
+		if err != nil {
+			return nil, &SyntaxError{Line: token.Line, Message: err.Error()}
+		}
+		src = strings.TrimSpace(src[end+1:])
+	}
+
+	open := strings.IndexByte(src, '(')
+	if open < 0 || !strings.HasSuffix(src, ")") {
+		return nil, &SyntaxError{Line: token.Line, Message: fmt.Sprintf("expected a macro call in '%s'", token.Content)}
+	}
+	end, err := closingParenthesis(src[open:])
+	if err != nil || open+end != len(src)-1 {
+		return nil, &SyntaxError{Line: token.Line, Message: fmt.Sprintf("expected a macro call in '%s'", token.Content)}
+	}
+
+	args, kwargs, err := ParseArguments(src[open+1 : len(src)-1])
+	if err != nil {
+		return nil, &SyntaxError{Line: token.Line, Message: fmt.Sprintf("invalid arguments in '%s': %s", token.Content, err.Error())}
+	}
+	return &CallBlock{
+		Macro:  strings.TrimSpace(src[:open]),
+		Args:   args,
+		Kwargs: kwargs,
+		Open:   token,
+	}, nil
+}
+
+// closingParenthesis returns the index of the parenthesis closing the one src starts with
+func closingParenthesis(src string) (int, error) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced parentheses in '%s'", src)
+}
@@ -0,0 +1,69 @@
+package jinja_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler/jinja"
+)
+
+func Test_Parse(t *testing.T) {
+	src := strings.TrimSpace(`
+{% call dbt_unit_testing.test('model', 'test') %}
+	{%- call dbt_unit_testing.mock_ref('a', {'source_file': 'a.csv'}) -%}
+	{%- endcall %}
+	{% call(row) dbt_unit_testing.mock_source('s', 'b', options={'source_file': 'b.csv'}) %}{% endcall %}{% call dbt_unit_testing.expect() %}
+select 1
+	{% endcall %}
+{% endcall %}
+`)
+	template, err := jinja.Parse([]byte(src))
+	assert.Nil(t, err)
+	assert.Len(t, template.Calls, 1)
+
+	var calls []*jinja.CallBlock
+	template.Walk(func(call *jinja.CallBlock) { calls = append(calls, call) })
+	assert.Len(t, calls, 4)
+
+	assert.Equal(t, "dbt_unit_testing.test", calls[0].Macro)
+	assert.Equal(t, []interface{}{"model", "test"}, calls[0].Args)
+	assert.Len(t, calls[0].Children, 3)
+
+	mockRef := calls[1]
+	assert.Equal(t, "dbt_unit_testing.mock_ref", mockRef.Macro)
+	assert.Equal(t, []interface{}{"a", map[string]interface{}{"source_file": "a.csv"}}, mockRef.Args)
+	assert.Equal(t, 2, mockRef.Open.Line)
+	assert.Equal(t, 3, mockRef.Close.Line)
+	assert.True(t, mockRef.Close.TrimBefore)
+	assert.Equal(t, "\n\t", src[mockRef.BodyStart():mockRef.BodyEnd()])
+
+	mockSource := calls[2]
+	assert.Equal(t, "dbt_unit_testing.mock_source", mockSource.Macro)
+	assert.Equal(t, map[string]interface{}{"options": map[string]interface{}{"source_file": "b.csv"}}, mockSource.Kwargs)
+	assert.Equal(t, mockSource.BodyStart(), mockSource.BodyEnd())
+	assert.Equal(t, "{% endcall %}", src[mockSource.Close.Start:mockSource.Close.End])
+
+	expect := calls[3]
+	assert.Equal(t, 4, expect.Open.Line)
+	assert.Equal(t, "\nselect 1\n\t", src[expect.BodyStart():expect.BodyEnd()])
+}
+
+func Test_Parse_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{name: "unclosed call", src: "{% call a() %}\n{% call b() %}{% endcall %}", expected: "line 1: 'call' block is never closed with 'endcall'"},
+		{name: "endcall without call", src: "\n{% endcall %}", expected: "line 2: 'endcall' without a matching 'call'"},
+		{name: "no macro call", src: "{% call a %}{% endcall %}", expected: "line 1: expected a macro call in 'call a'"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jinja.Parse([]byte(tt.src))
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
@@ -1,15 +1,21 @@
 package templatecrawler
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
-	"io"
 	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
-	"time"
+	"unicode/utf8"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/selection"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler/jinja"
+	"github.com/tsanton/dbt-unit-test-fusionizer/utilities"
 )
 
 type TestTemplateFile struct {
@@ -18,8 +24,11 @@ type TestTemplateFile struct {
 	relativeFileDir      string
 	absFileDir           string
 	fileName             string
-	lastChanged          time.Time
+	hash                 string
+	content              []byte
+	adapter              Adapter
 	dataSourceReferences []DataSourceReference
+	failed               bool
 }
 
 func (s *TestTemplateFile) RelativeFilePath() string {
@@ -34,8 +43,20 @@ func (s *TestTemplateFile) AbsFilePath() string {
 	return filepath.Join(s.absFileDir, s.fileName)
 }
 
-func (s *TestTemplateFile) LastChanged() time.Time {
-	return s.lastChanged
+// Hash returns the SHA-256 digest of the test template content
+func (s *TestTemplateFile) Hash() string {
+	return s.hash
+}
+
+// Content returns the test template as it was read and parsed. The data source references point into this content
+func (s *TestTemplateFile) Content() []byte {
+	return s.content
+}
+
+// Failed reports whether the test template could not be read or parsed. A failed template has no data source references and is not rendered,
+// but its generated test file is still known to the lock file and is not orphaned
+func (s *TestTemplateFile) Failed() bool {
+	return s.failed
 }
 
 func (s *TestTemplateFile) DataSourceReferences() *[]DataSourceReference {
@@ -46,10 +67,11 @@ func NewTestTemplateFile(logger *slog.Logger, baseTemplateDir string) *TestTempl
 	return &TestTemplateFile{
 		logger:          logger,
 		baseTemplateDir: baseTemplateDir,
+		adapter:         DefaultAdapter(),
 	}
 }
 
-func (s *TestTemplateFile) ProccessFile(inputFilePath string) {
+func (s *TestTemplateFile) ProccessFile(inputFilePath string) error {
 	var err error
 	s.logger.Debug(fmt.Sprintf("processing test template file: '%s", inputFilePath))
 	s.fileName = filepath.Base(inputFilePath)
@@ -61,112 +83,353 @@ func (s *TestTemplateFile) ProccessFile(inputFilePath string) {
 		s.logger.Debug("error calculating relative path of template file to test directory")
 	}
 
-	file, err := s.openFile(inputFilePath)
+	content, err := s.readFile(inputFilePath)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("error processing test template file '%s': %s", inputFilePath, err.Error()))
+		s.failed = true
+		return err
 	}
-	defer file.Close()
-	err = s.parseFile(file)
+	err = s.parseFile(content)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("error processing test template file '%s': %s", inputFilePath, err.Error()))
+		s.failed = true
+		s.dataSourceReferences = nil
+		return err
 	}
+	return nil
 }
 
-func (s *TestTemplateFile) openFile(path string) (io.ReadCloser, error) {
-	fileInfo, err := os.Stat(path)
+func (s *TestTemplateFile) readFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get file info: %w", err)
+		return nil, fmt.Errorf("cannot read file: %w", err)
 	}
-	s.lastChanged = fileInfo.ModTime()
+	s.hash = utilities.Sha256(content)
+	s.content = content
+	return content, nil
+}
 
-	// Open the file
-	file, err := os.Open(path)
+func (s *TestTemplateFile) parseFile(content []byte) error {
+	template, err := jinja.Parse(content)
 	if err != nil {
-		return nil, fmt.Errorf("cannot open file: %w", err)
+		return fmt.Errorf("error parsing template: %w", err)
 	}
-	return file, nil
+
+	tests := map[*jinja.CallBlock]int{}
+	s.testLines(template.Calls, 0, tests)
+
+	var references []DataSourceReference
+	template.Walk(func(call *jinja.CallBlock) {
+		if err != nil || !s.adapter.isMockMacro(call.Macro) {
+			return
+		}
+		options := callOptions(call)
+
+		var paths []string
+		var inlineContent []byte
+		sourceFile, hasSourceFile := options[s.adapter.SourceFileKey]
+		if inlineFormat, ok := options["inline_format"]; ok {
+			if hasSourceFile {
+				err = fmt.Errorf("line %d: '%s' and 'inline_format' of '%s' cannot be combined", call.Open.Line, s.adapter.SourceFileKey, call.Macro)
+				return
+			}
+			if inlineFormat != string(formatter.ParserInputTypeCsv) {
+				err = fmt.Errorf("line %d: unsupported inline_format '%v', expected 'csv'", call.Open.Line, inlineFormat)
+				return
+			}
+			inlineContent = inlineBody(content, call)
+			paths = []string{fmt.Sprintf("%s:%d", s.AbsFilePath(), call.Open.Line)}
+			s.logger.Debug(fmt.Sprintf("inline data source in line %d of test template file '%s'", call.Open.Line, s.AbsFilePath()))
+		} else if hasSourceFile {
+			var pathErr error
+			paths, pathErr = s.sourceFilePaths(sourceFile)
+			if pathErr != nil {
+				err = fmt.Errorf("line %d: '%s' of '%s' %w", call.Open.Line, s.adapter.SourceFileKey, call.Macro, pathErr)
+				return
+			}
+			for _, path := range paths {
+				s.logger.Debug(fmt.Sprintf("source file '%s' referenced in test template file '%s'", path, s.AbsFilePath()))
+			}
+		} else {
+			return
+		}
+
+		inputFormat, separator, formatErr := inputFormatOptions(options)
+		if formatErr != nil {
+			err = fmt.Errorf("line %d: %w", call.Open.Line, formatErr)
+			return
+		}
+		sel, selectionErr := selectionOptions(options)
+		if selectionErr != nil {
+			err = fmt.Errorf("line %d: %w", call.Open.Line, selectionErr)
+			return
+		}
+		vars, varsErr := varsOption(options)
+		if varsErr != nil {
+			err = fmt.Errorf("line %d: %w", call.Open.Line, varsErr)
+			return
+		}
+		if vars != "" {
+			// The data sources are parsed once per combination of vars
+			for i := range paths {
+				paths[i] += "?" + vars
+			}
+		}
+
+		references = append(references, DataSourceReference{
+			TestDefintionFilePath: s.absFileDir,
+			DataSourceFilePaths:   paths,
+			InputFormat:           inputFormat,
+			ColumnSeparator:       separator,
+			Selection:             sel,
+			InlineContent:         inlineContent,
+			Mock:                  mockName(call),
+			TestLine:              tests[call],
+			CallLine:              call.Open.Line,
+			EndCallLine:           call.Close.Line,
+			BodyOffset:            call.BodyStart(),
+			EndCallOffset:         call.Close.Start,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.dataSourceReferences = references
+	return nil
 }
 
-var funcCallRegex = regexp.MustCompile(`dbt_unit_testing.mock_ref|dbt_unit_testing.mock_source|dbt_unit_testing.expect`)
-var endCallRegex = regexp.MustCompile(`{%\s*endcall\s*%}`)
+// testLines records the line of the test call every call block is nested in, so that the mocks of a test can be related to each other
+func (s *TestTemplateFile) testLines(calls []*jinja.CallBlock, line int, lines map[*jinja.CallBlock]int) {
+	for _, call := range calls {
+		lines[call] = line
+		inner := line
+		if s.adapter.isTestMacro(call.Macro) {
+			inner = call.Open.Line
+		}
+		s.testLines(call.Children, inner, lines)
+	}
+}
 
-var sourceFileRegex = regexp.MustCompile(`\s*(?:'|")source_file(?:'|")\s*:\s*(?:'|")([^']*)(?:'|")`)
+// mockName returns the mocked model or source of a mock call, its string arguments joined with '.', e.g. 'raw.customers' for "mock_source('raw', 'customers')"
+func mockName(call *jinja.CallBlock) string {
+	var names []string
+	for _, arg := range call.Args {
+		if name, ok := arg.(string); ok {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ".")
+}
 
-var dbtInputFormatRegex = regexp.MustCompile(`\s*(?:'|")input_format(?:'|")\s*:\s*(?:'|")([^']*)(?:'|")`)
+// inlineBody returns the csv written in the body of a mock call with the common indentation removed.
+// Every line of the template before the body is kept as an empty line, so that parse errors refer to the lines of the template
+func inlineBody(content []byte, call *jinja.CallBlock) []byte {
+	lines := strings.SplitAfter(string(content[call.BodyStart():call.BodyEnd()]), "\n")
+	indentation := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndentation := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indentation = lineIndentation
+			first = false
+		} else {
+			indentation = commonPrefix(indentation, lineIndentation)
+		}
+	}
 
-func lineEndsWithClosingTag(line string) bool {
-	line = strings.TrimSpace(line)
-	return strings.HasSuffix(line, "%}")
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("\n", bytes.Count(content[:call.BodyStart()], []byte("\n"))))
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			// Keep the line count, but drop the whitespace, e.g. the indentation of the 'endcall'
+			sb.WriteString(line[len(strings.TrimRight(line, "\n")):])
+			continue
+		}
+		sb.WriteString(strings.TrimPrefix(line, indentation))
+	}
+	return []byte(sb.String())
 }
 
-func (s *TestTemplateFile) parseFile(file io.ReadCloser) error {
-	scanner := bufio.NewScanner(file)
-	lineNumber := 0
-	callStack := make([]int, 0)
+// commonPrefix returns the longest common prefix of a and b
+func commonPrefix(a string, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		lineNumber++
-		if funcCallRegex.MatchString(line) {
-		EndCallNotOneLiner:
-			endsWithBlockCloser := lineEndsWithClosingTag(line)
-			if !endsWithBlockCloser {
-				if !scanner.Scan() {
-					// Handle the case where there are no more lines to read
-					break
-				}
-				line += scanner.Text()
-				lineNumber++
-				goto EndCallNotOneLiner
+// sourceFilePaths resolves the source file option of a mock call, a path or glob or a list of them, to absolute paths.
+// Relative paths are resolved against the directory of the test template, and the matches of a glob are sorted.
+// A '#name' suffix references a fixture of the file and is kept on the resolved paths
+func (s *TestTemplateFile) sourceFilePaths(sourceFile interface{}) ([]string, error) {
+	var patterns []string
+	switch value := sourceFile.(type) {
+	case string:
+		patterns = []string{value}
+	case []interface{}:
+		for _, item := range value {
+			pattern, isString := item.(string)
+			if !isString {
+				return nil, fmt.Errorf("must be a non-empty string or a list of strings")
 			}
-			//Verfied: "% }" is considered malformed by DBT -> i.e. we can expect "%}"
-			//Verified: in Jinja2 and DBT you cannot have a one-liner macro call without the expected {% endcall %}. The {% endcall %} statement is required to explicitly close the {% call %} block
-			var sourceFile string
-			sourceMatches := sourceFileRegex.FindStringSubmatch(line)
-			if len(sourceMatches) >= 2 {
-				// sourceFile = sourceMatches[1]
-				//TODO: verify
-				if filepath.IsAbs(sourceMatches[1]) {
-					sourceFile = sourceMatches[1]
-				} else {
-					sourceFile, _ = filepath.Abs(filepath.Join(s.absFileDir, sourceMatches[1]))
-				}
-				s.logger.Debug(fmt.Sprintf("source file '%s' referenced in test template file '%s'", sourceFile, s.AbsFilePath()))
+			patterns = append(patterns, pattern)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("must be a non-empty string or a list of strings")
+	}
+
+	var paths []string
+	for _, pattern := range patterns {
+		pattern, fragment, hasFragment := strings.Cut(pattern, "#")
+		if pattern == "" || (hasFragment && fragment == "") {
+			return nil, fmt.Errorf("must not contain empty paths or fixture names")
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern, _ = filepath.Abs(filepath.Join(s.absFileDir, pattern))
+		}
+		matches := []string{pattern}
+		if strings.ContainsAny(pattern, "*?[") {
+			var err error
+			matches, err = filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("has an invalid pattern '%s': %w", pattern, err)
 			}
-			dbtInputFormatMatches := dbtInputFormatRegex.FindStringSubmatch(line)
-			if len(dbtInputFormatMatches) >= 2 {
-				if dbtInputFormatMatches[1] != "sql" {
-					s.logger.Error("dbt_unit_testing templated by the datasourcerer supports sql input format only")
-					//TODO: return error?
-				}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("pattern '%s' does not match any file", pattern)
 			}
-			if sourceFile != "" {
-				ds := DataSourceReference{
-					TestDefintionFilePath: s.absFileDir,
-					DataSourceFilePath:    sourceFile,
-					CallLine:              lineNumber,
-				}
-				if endCallRegex.MatchString(line) {
-					ds.EndCallLine = lineNumber
-					s.dataSourceReferences = append(s.dataSourceReferences, ds)
-				} else {
-					s.dataSourceReferences = append(s.dataSourceReferences, ds)
-					callStack = append(callStack, len(s.dataSourceReferences)-1)
-				}
+			sort.Strings(matches)
+		}
+		for _, match := range matches {
+			if hasFragment {
+				// A fixture of a file split into '# fixture: <name>' sections
+				match += "#" + fragment
 			}
-			//TODO: if not found endcall before union or end of file, empty result and return error
-		} else if endCallRegex.MatchString(line) || strings.Contains(line, "UNION ALL") {
-			if len(callStack) > 0 {
-				index := callStack[len(callStack)-1]
-				callStack = callStack[:len(callStack)-1]
-				s.dataSourceReferences[index].EndCallLine = lineNumber
+			paths = append(paths, match)
+		}
+	}
+	return paths, nil
+}
+
+// inputFormatOptions returns the 'input_format' of a mock call, 'sql' by default, and the 'column_separator' used by csv input, ',' by default
+func inputFormatOptions(options map[string]interface{}) (formatter.ParserInputType, rune, error) {
+	inputFormat := formatter.ParserInputTypeSql
+	if value, ok := options["input_format"]; ok {
+		switch value {
+		case string(formatter.ParserInputTypeSql), string(formatter.ParserInputTypeCsv):
+			inputFormat = formatter.ParserInputType(value.(string))
+		default:
+			return "", 0, fmt.Errorf("unsupported input_format '%v', expected 'sql' or 'csv'", value)
+		}
+	}
+
+	separator := ','
+	if value, ok := options["column_separator"]; ok {
+		sep, isString := value.(string)
+		if !isString || utf8.RuneCountInString(sep) != 1 {
+			return "", 0, fmt.Errorf("column_separator '%v' must be a single character", value)
+		}
+		separator, _ = utf8.DecodeRuneInString(sep)
+	}
+	return inputFormat, separator, nil
+}
+
+// selectionOptions returns the subset of the data source selected by the 'rows', 'where' and 'columns' options of a mock call,
+// or nil when the call selects the whole data source
+func selectionOptions(options map[string]interface{}) (*selection.Selection, error) {
+	rows, hasRows := options["rows"]
+	where, hasWhere := options["where"]
+	columns, hasColumns := options["columns"]
+	if !hasRows && !hasWhere && !hasColumns {
+		return nil, nil
+	}
+
+	sel := &selection.Selection{}
+	var err error
+	if hasRows {
+		switch value := rows.(type) {
+		case string:
+			sel.Rows, err = selection.ParseRows(value)
+		case int64:
+			sel.Rows, err = selection.ParseRows(fmt.Sprint(value))
+		default:
+			err = fmt.Errorf("rows '%v' must be a string of row numbers and ranges, e.g. '1-5,9'", value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hasWhere {
+		condition, isString := where.(string)
+		if !isString {
+			return nil, fmt.Errorf("where '%v' must be a string", where)
+		}
+		if sel.Where, err = selection.ParseWhere(condition); err != nil {
+			return nil, err
+		}
+	}
+	if hasColumns {
+		list, isList := columns.([]interface{})
+		if !isList || len(list) == 0 {
+			return nil, fmt.Errorf("columns '%v' must be a non-empty list of column names", columns)
+		}
+		for _, column := range list {
+			name, isString := column.(string)
+			if !isString || name == "" {
+				return nil, fmt.Errorf("columns '%v' must be a non-empty list of column names", columns)
 			}
+			sel.Columns = append(sel.Columns, name)
 		}
 	}
+	return sel, nil
+}
+
+var varNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
 
-	if err := scanner.Err(); err != nil {
-		s.dataSourceReferences = []DataSourceReference{}
-		return fmt.Errorf("error scanning file: %w", err)
+// varsOption returns the 'vars' of a mock call encoded as a query, e.g. 'customer_id=42&status=open', or an empty string when the call has none.
+// The values substitute the '${name}' and '{{ name }}' placeholders of the data sources
+func varsOption(options map[string]interface{}) (string, error) {
+	value, ok := options["vars"]
+	if !ok {
+		return "", nil
 	}
-	return nil
+	dict, isDict := value.(map[string]interface{})
+	if !isDict {
+		return "", fmt.Errorf("vars '%v' must be a dict of names and values", value)
+	}
+	vars := url.Values{}
+	for name, v := range dict {
+		if !varNameRegex.MatchString(name) {
+			return "", fmt.Errorf("invalid var name '%s', expected letters, digits and '_'", name)
+		}
+		switch v.(type) {
+		case string, int64, float64, bool:
+			vars.Set(name, fmt.Sprint(v))
+		default:
+			return "", fmt.Errorf("var '%s' must be a string, number or boolean, got '%v'", name, v)
+		}
+	}
+	return vars.Encode(), nil
+}
+
+// callOptions returns the options dict of a mock call, passed either positionally or as the 'options' keyword argument
+func callOptions(call *jinja.CallBlock) map[string]interface{} {
+	options := map[string]interface{}{}
+	for _, arg := range call.Args {
+		if dict, ok := arg.(map[string]interface{}); ok {
+			for key, value := range dict {
+				options[key] = value
+			}
+		}
+	}
+	if dict, ok := call.Kwargs["options"].(map[string]interface{}); ok {
+		for key, value := range dict {
+			options[key] = value
+		}
+	}
+	return options
 }
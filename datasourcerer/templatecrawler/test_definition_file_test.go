@@ -0,0 +1,256 @@
+package templatecrawler_test
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/selection"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+)
+
+func Test_TestTemplateFile_InputFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   string
+		format    formatter.ParserInputType
+		separator rune
+		err       string
+	}{
+		{name: "default", options: `{'source_file': 'ds.csv'}`, format: formatter.ParserInputTypeSql, separator: ','},
+		{name: "sql", options: `{'source_file': 'ds.csv', 'input_format': 'sql'}`, format: formatter.ParserInputTypeSql, separator: ','},
+		{name: "csv", options: `{'source_file': 'ds.csv', 'input_format': 'csv'}`, format: formatter.ParserInputTypeCsv, separator: ','},
+		{name: "csv separator", options: `{'source_file': 'ds.csv', 'input_format': 'csv', 'column_separator': '|'}`, format: formatter.ParserInputTypeCsv, separator: '|'},
+		{name: "unsupported format", options: `{'source_file': 'ds.csv', 'input_format': 'json'}`, err: "line 1: unsupported input_format 'json', expected 'sql' or 'csv'"},
+		{name: "invalid separator", options: `{'source_file': 'ds.csv', 'input_format': 'csv', 'column_separator': ';;'}`, err: "line 1: column_separator ';;' must be a single character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "test_model.sql")
+			content := "{% call dbt_unit_testing.mock_ref('model', " + tt.options + ") %}\n{% endcall %}\n"
+			assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+
+			file := templatecrawler.NewTestTemplateFile(slog.Default(), dir)
+			err := file.ProccessFile(path)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			references := *file.DataSourceReferences()
+			assert.Len(t, references, 1)
+			assert.Equal(t, tt.format, references[0].InputFormat)
+			assert.Equal(t, tt.separator, references[0].ColumnSeparator)
+		})
+	}
+}
+
+func Test_TestTemplateFile_SourceFiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		sourceFile string
+		expected   []string
+		err        string
+	}{
+		{name: "path", sourceFile: `'fixtures/orders_1.csv'`, expected: []string{"fixtures/orders_1.csv"}},
+		{name: "missing path", sourceFile: `'fixtures/missing.csv'`, expected: []string{"fixtures/missing.csv"}},
+		{name: "list", sourceFile: `['fixtures/orders_2.csv', 'base.csv']`, expected: []string{"fixtures/orders_2.csv", "base.csv"}},
+		{name: "glob", sourceFile: `'fixtures/orders_*.csv'`, expected: []string{"fixtures/orders_1.csv", "fixtures/orders_2.csv"}},
+		{name: "list with glob", sourceFile: `['base.csv', 'fixtures/*_2.csv']`, expected: []string{"base.csv", "fixtures/orders_2.csv"}},
+		{name: "glob without match", sourceFile: `'fixtures/customers_*.csv'`, err: "pattern '${dir}/fixtures/customers_*.csv' does not match any file"},
+		{name: "empty list", sourceFile: `[]`, err: "must be a non-empty string or a list of strings"},
+		{name: "list of numbers", sourceFile: `[1, 2]`, err: "must be a non-empty string or a list of strings"},
+		{name: "empty path", sourceFile: `['base.csv', '']`, err: "must not contain empty paths or fixture names"},
+		{name: "fragment", sourceFile: `['base.csv#late', 'fixtures/*_1.csv#early']`, expected: []string{"base.csv#late", "fixtures/orders_1.csv#early"}},
+		{name: "empty fragment", sourceFile: `'base.csv#'`, err: "must not contain empty paths or fixture names"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			assert.Nil(t, os.MkdirAll(filepath.Join(dir, "fixtures"), 0755))
+			for _, fixture := range []string{"base.csv", "fixtures/orders_1.csv", "fixtures/orders_2.csv"} {
+				assert.Nil(t, os.WriteFile(filepath.Join(dir, fixture), []byte("id\n1"), 0644))
+			}
+			path := filepath.Join(dir, "test_model.sql")
+			content := "{% call dbt_unit_testing.mock_ref('model', {'source_file': " + tt.sourceFile + "}) %}\n{% endcall %}\n"
+			assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+
+			file := templatecrawler.NewTestTemplateFile(slog.Default(), dir)
+			err := file.ProccessFile(path)
+			if tt.err != "" {
+				assert.EqualError(t, err, "line 1: 'source_file' of 'dbt_unit_testing.mock_ref' "+strings.ReplaceAll(tt.err, "${dir}", dir))
+				return
+			}
+			assert.Nil(t, err)
+			references := *file.DataSourceReferences()
+			assert.Len(t, references, 1)
+			expected := make([]string, len(tt.expected))
+			for i, fixture := range tt.expected {
+				expected[i] = filepath.Join(dir, fixture)
+			}
+			assert.Equal(t, expected, references[0].DataSourceFilePaths)
+		})
+	}
+}
+
+func Test_TestTemplateFile_Selection(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		rows    selection.Ranges
+		columns []string
+		where   bool
+		err     string
+	}{
+		{name: "none", options: ``},
+		{name: "rows", options: `, 'rows': '1-5,9'`, rows: selection.Ranges{{From: 1, To: 5}, {From: 9, To: 9}}},
+		{name: "single row", options: `, 'rows': 2`, rows: selection.Ranges{{From: 2, To: 2}}},
+		{name: "where", options: `, 'where': "status = 'open'"`, where: true},
+		{name: "columns", options: `, 'columns': ['id', 'status']`, columns: []string{"id", "status"}},
+		{name: "invalid rows", options: `, 'rows': '5-1'`, err: "line 1: invalid rows '5-1': range '5-1' ends before it starts"},
+		{name: "invalid where", options: `, 'where': "status ="`, err: "line 1: invalid where 'status =': expected a value"},
+		{name: "where not a string", options: `, 'where': 1`, err: "line 1: where '1' must be a string"},
+		{name: "columns not a list", options: `, 'columns': 'id'`, err: "line 1: columns 'id' must be a non-empty list of column names"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "test_model.sql")
+			content := "{% call dbt_unit_testing.mock_ref('model', {'source_file': 'ds.csv'" + tt.options + "}) %}\n{% endcall %}\n"
+			assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+
+			file := templatecrawler.NewTestTemplateFile(slog.Default(), dir)
+			err := file.ProccessFile(path)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			sel := (*file.DataSourceReferences())[0].Selection
+			if tt.options == "" {
+				assert.Nil(t, sel)
+				return
+			}
+			assert.Equal(t, tt.rows, sel.Rows)
+			assert.Equal(t, tt.columns, sel.Columns)
+			assert.Equal(t, tt.where, sel.Where != nil)
+		})
+	}
+}
+
+func Test_TestTemplateFile_Inline(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		err      string
+	}{
+		{
+			name:     "dedent",
+			content:  "\n{% call dbt_unit_testing.mock_ref('model', {'inline_format': 'csv'}) %}\n\t\tid,name\n\t\t  1,John\n\n\t{% endcall %}\n",
+			expected: "\n\nid,name\n  1,John\n\n",
+		},
+		{
+			name:     "same line",
+			content:  "{% call dbt_unit_testing.mock_ref('model', {'inline_format': 'csv'}) %}id\n1{% endcall %}\n",
+			expected: "id\n1",
+		},
+		{
+			name:    "source file",
+			content: "{% call dbt_unit_testing.mock_ref('model', {'inline_format': 'csv', 'source_file': 'ds.csv'}) %}\n{% endcall %}\n",
+			err:     "line 1: 'source_file' and 'inline_format' of 'dbt_unit_testing.mock_ref' cannot be combined",
+		},
+		{
+			name:    "unsupported format",
+			content: "{% call dbt_unit_testing.mock_ref('model', {'inline_format': 'sql'}) %}\n{% endcall %}\n",
+			err:     "line 1: unsupported inline_format 'sql', expected 'csv'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "test_model.sql")
+			assert.Nil(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			file := templatecrawler.NewTestTemplateFile(slog.Default(), dir)
+			err := file.ProccessFile(path)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			references := *file.DataSourceReferences()
+			assert.Len(t, references, 1)
+			assert.Equal(t, tt.expected, string(references[0].InlineContent))
+			assert.Equal(t, []string{fmt.Sprintf("%s:%d", path, references[0].CallLine)}, references[0].DataSourceFilePaths)
+		})
+	}
+}
+
+func Test_TestTemplateFile_Vars(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  string
+		expected string
+		err      string
+	}{
+		{name: "none", options: ``, expected: "ds.csv"},
+		{name: "empty", options: `, 'vars': {}`, expected: "ds.csv"},
+		{name: "vars", options: `, 'vars': {'status': 'open', 'customer_id': 42, 'active': true}`, expected: "ds.csv?active=true&customer_id=42&status=open"},
+		{name: "escaped", options: `, 'vars': {'note': 'a&b c'}`, expected: "ds.csv?note=a%26b+c"},
+		{name: "not a dict", options: `, 'vars': ['customer_id']`, err: "line 1: vars '[customer_id]' must be a dict of names and values"},
+		{name: "invalid name", options: `, 'vars': {'customer-id': 42}`, err: "line 1: invalid var name 'customer-id', expected letters, digits and '_'"},
+		{name: "invalid value", options: `, 'vars': {'ids': [1, 2]}`, err: "line 1: var 'ids' must be a string, number or boolean, got '[1 2]'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "test_model.sql")
+			content := "{% call dbt_unit_testing.mock_ref('model', {'source_file': 'ds.csv'" + tt.options + "}) %}\n{% endcall %}\n"
+			assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+
+			file := templatecrawler.NewTestTemplateFile(slog.Default(), dir)
+			err := file.ProccessFile(path)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, []string{filepath.Join(dir, tt.expected)}, (*file.DataSourceReferences())[0].DataSourceFilePaths)
+		})
+	}
+}
+
+func Test_TestTemplateFile_Mocks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test_model.sql")
+	content := `{% call dbt_unit_testing.test('orders_summary', 'first') %}
+  {% call dbt_unit_testing.mock_ref('orders', {'source_file': 'orders.csv'}) %}
+  {% endcall %}
+  {% call dbt_unit_testing.mock_source('raw', 'customers', {'source_file': 'customers.csv'}) %}
+  {% endcall %}
+{% endcall %}
+{% call dbt_unit_testing.test('orders_summary', 'second') %}
+  {% call dbt_unit_testing.mock_ref('orders', {'source_file': 'orders.csv'}) %}
+  {% endcall %}
+{% endcall %}
+{% call dbt_unit_testing.mock_ref('orders', {'source_file': 'orders.csv'}) %}
+{% endcall %}
+`
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0644))
+
+	file := templatecrawler.NewTestTemplateFile(slog.Default(), dir)
+	assert.Nil(t, file.ProccessFile(path))
+
+	var mocks []string
+	for _, reference := range *file.DataSourceReferences() {
+		mocks = append(mocks, fmt.Sprintf("%s:%d", reference.Mock, reference.TestLine))
+	}
+	assert.Equal(t, []string{"orders:1", "raw.customers:1", "orders:7", "orders:0"}, mocks)
+}
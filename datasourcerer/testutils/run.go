@@ -1,18 +1,30 @@
 package testutils
 
 import (
+	"io"
 	"log/slog"
 
 	"github.com/tsanton/dbt-unit-test-fusionizer/datasourceparser"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
 	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake"
 	"github.com/tsanton/dbt-unit-test-fusionizer/generator"
+	"github.com/tsanton/dbt-unit-test-fusionizer/relationship"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
 	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
 )
 
-func Run(logger *slog.Logger, config *formatter.Config, rootDir string, outputDir string) {
+// adapter returns the mocks adapter of the config, and panics on invalid test configs
+func adapter(config *formatter.Config) templatecrawler.Adapter {
+	adapter, err := templatecrawler.NewAdapter(config.Mocks)
+	if err != nil {
+		panic(err)
+	}
+	return adapter
+}
+
+func crawlAndParse(logger *slog.Logger, config *formatter.Config, rootDir string) (*[]templatecrawler.TestTemplateFile, *map[string]datasourceparser.DataSourceFile) {
 	c := make(chan templatecrawler.DataSourceReference)
-	dbtCrawler := templatecrawler.NewTestTemplateCrawler(logger, 1, rootDir)
+	dbtCrawler := templatecrawler.NewTestTemplateCrawler(logger, 1, rootDir).WithAdapter(adapter(config))
 	dataSourceParser := datasourceparser.NewDatasourceParser(
 		logger,
 		1,
@@ -21,10 +33,95 @@ func Run(logger *slog.Logger, config *formatter.Config, rootDir string, outputDi
 	)
 	go dbtCrawler.Crawl(c)
 	dataSourceParser.Parse(c)
+	return dbtCrawler.GetTestTemplates(), dataSourceParser.GetDataSources()
+}
 
+func Run(logger *slog.Logger, config *formatter.Config, rootDir string, outputDir string) {
 	//Generate the dbt files with formatted inputted data
-	tDefs := dbtCrawler.GetTestTemplates()
-	dDefs := dataSourceParser.GetDataSources()
+	tDefs, dDefs := crawlAndParse(logger, config, rootDir)
 	generator := generator.NewTestGenerator(logger, 1, outputDir)
 	_ = generator.Generate(tDefs, dDefs)
 }
+
+// Check runs the pipeline like Run, but compares the result with the generated files instead of writing them
+func Check(logger *slog.Logger, config *formatter.Config, rootDir string, outputDir string) ([]generator.FileCheck, error) {
+	tDefs, dDefs := crawlAndParse(logger, config, rootDir)
+	generator := generator.NewTestGenerator(logger, 1, outputDir)
+	return generator.Check(tDefs, dDefs, true)
+}
+
+// Diff runs the pipeline like Run, but writes a unified diff of the changes to w instead of writing the generated files
+func Diff(logger *slog.Logger, config *formatter.Config, rootDir string, outputDir string, w io.Writer) (generator.DiffSummary, error) {
+	tDefs, dDefs := crawlAndParse(logger, config, rootDir)
+	generator := generator.NewTestGenerator(logger, 1, outputDir)
+	return generator.Diff(w, tDefs, dDefs)
+}
+
+// Validate crawls the test templates and parses the data sources like Run, and checks the relationships of the config between the mocks of every test
+func Validate(logger *slog.Logger, config *formatter.Config, rootDir string, outputDir string) ([]report.Issue, int, error) {
+	relationships, err := relationship.ParseAll(config.Relationships)
+	if err != nil {
+		return nil, 0, err
+	}
+	tDefs, dDefs := crawlAndParse(logger, config, rootDir)
+	generator := generator.NewTestGenerator(logger, 1, outputDir)
+	issues, checked := generator.Validate(tDefs, dDefs, relationships)
+	return issues, checked, nil
+}
+
+// Prune crawls the test templates and deletes the orphaned generated files in outputDir, or only lists them when dryRun is set
+func Prune(logger *slog.Logger, config *formatter.Config, rootDir string, outputDir string, dryRun bool) ([]string, error) {
+	c := make(chan templatecrawler.DataSourceReference)
+	dbtCrawler := templatecrawler.NewTestTemplateCrawler(logger, 1, rootDir).WithAdapter(adapter(config))
+	go dbtCrawler.Crawl(c)
+	for range c {
+	}
+	generator := generator.NewTestGenerator(logger, 1, outputDir)
+	return generator.Prune(dbtCrawler.GetTestTemplates(), dbtCrawler.Issues(), dryRun)
+}
+
+// RunLocked runs the pipeline like Run, but only regenerates the tests whose hashes changed since they were recorded in the lock file
+func RunLocked(logger *slog.Logger, config *formatter.Config, rootDir string, outputDir string, lockPath string, version string) error {
+	tDefs, dDefs := crawlAndParse(logger, config, rootDir)
+	lock, err := generator.LoadLockFile(lockPath, version, "config")
+	if err != nil {
+		return err
+	}
+	lock.Retain(tDefs)
+	generator := generator.NewTestGenerator(logger, 1, outputDir).WithLockFile(lock)
+	return generator.Generate(tDefs, dDefs)
+}
+
+// RunReport runs the pipeline like Run and returns the report of every crawler, parser and generator error
+func RunReport(logger *slog.Logger, config *formatter.Config, rootDir string, outputDir string) (*report.Report, error) {
+	c := make(chan templatecrawler.DataSourceReference)
+	dbtCrawler := templatecrawler.NewTestTemplateCrawler(logger, 1, rootDir).WithAdapter(adapter(config))
+	dataSourceParser := datasourceparser.NewDatasourceParser(
+		logger,
+		1,
+		config,
+		snowflake.Constructor(),
+	)
+	go dbtCrawler.Crawl(c)
+	dataSourceParser.Parse(c)
+	tDefs, dDefs := dbtCrawler.GetTestTemplates(), dataSourceParser.GetDataSources()
+
+	generator := generator.NewTestGenerator(logger, 1, outputDir)
+	err := generator.Generate(tDefs, dDefs)
+
+	rep := report.New()
+	rep.Add(dbtCrawler.Issues()...)
+	rep.Add(dataSourceParser.Issues()...)
+	rep.Add(generator.Issues()...)
+	rep.Templates = len(*tDefs)
+	rep.DataSources = len(*dDefs)
+	rep.Generated = generator.Generated()
+	return rep, err
+}
+
+// RunWithErrorMode runs the pipeline like Run, rendering the data sources that failed to parse with the error mode
+func RunWithErrorMode(logger *slog.Logger, config *formatter.Config, rootDir string, outputDir string, mode generator.ErrorMode) error {
+	tDefs, dDefs := crawlAndParse(logger, config, rootDir)
+	generator := generator.NewTestGenerator(logger, 1, outputDir).WithErrorMode(mode)
+	return generator.Generate(tDefs, dDefs)
+}
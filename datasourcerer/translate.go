@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/translate"
+)
+
+// runTranslate rewrites the annotated header of a csv data source from the types of one dialect to the types of another, e.g. snowflake 'timestamp_ltz()' to postgres 'timestamp_tz()'.
+// The values are read with the source dialect and written in the default formats, the lossy conversions are reported on stderr, and the output is read back with the target dialect
+func runTranslate(args []string) int {
+	flags := flag.NewFlagSet("translate", flag.ContinueOnError)
+	in := flags.String("in", "", "The csv data source to translate")
+	out := flags.String("out", "", "The csv file to write. Defaults to stdout")
+	from := flags.String("from", "", "The dialect the data source is annotated for: 'snowflake' or 'postgres'")
+	to := flags.String("to", "", "The dialect to annotate the data source for: 'snowflake' or 'postgres'")
+	separator := flags.String("separator", ",", "The field delimiter of the csv")
+	strict := flags.Bool("strict", false, "Fail when a conversion is lossy")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *in == "" || *from == "" || *to == "" {
+		logger.Error("the 'in', 'from' and 'to' flags are required")
+		return 2
+	}
+	if len([]rune(*separator)) != 1 {
+		logger.Error(fmt.Sprintf("the separator must be a single character, got '%s'", *separator))
+		return 2
+	}
+
+	content, err := os.ReadFile(*in)
+	if err != nil {
+		logger.Error(fmt.Sprintf("error reading data source: %s", err.Error()))
+		return 1
+	}
+	source, err := csvFormatter(*from, *separator)
+	if err != nil {
+		logger.Error(err.Error())
+		return 2
+	}
+	if err := source.Read(bytes.NewReader(content)); err != nil {
+		logger.Error(fmt.Sprintf("error reading data source '%s': %s", *in, err.Error()))
+		return 1
+	}
+
+	csv := formatter.NewDefaultCsvConfig()
+	csv.Separator = *separator
+	var buf bytes.Buffer
+	conversions, err := translate.Translate(&buf, content, source.Table(), *from, *to, csv)
+	if err != nil {
+		logger.Error(fmt.Sprintf("error translating data source '%s': %s", *in, err.Error()))
+		return 1
+	}
+	lossy := 0
+	for _, conversion := range conversions {
+		if conversion.Lossy == "" {
+			logger.Debug(fmt.Sprintf("translated %s", conversion))
+			continue
+		}
+		lossy++
+		// The report goes to stderr whatever the logging level, stdout may hold the translated csv
+		fmt.Fprintf(os.Stderr, "lossy conversion of %s: %s\n", conversion, conversion.Lossy)
+	}
+
+	if err := validateSynth(buf.Bytes(), *to, *separator); err != nil {
+		logger.Error(fmt.Sprintf("translated data source is not valid: %s", err.Error()))
+		return 1
+	}
+	if *strict && lossy > 0 {
+		logger.Error(fmt.Sprintf("%d of %d conversion(s) are lossy", lossy, len(conversions)))
+		return 1
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(*out, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("error writing data source: %s", err.Error()))
+		return 1
+	}
+	return 0
+}
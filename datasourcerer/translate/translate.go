@@ -0,0 +1,223 @@
+package translate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+)
+
+// typedHeaderRegex matches an annotated header, e.g. 'amount[number(10,2)|min=0]'. Headers without a type are read as text by every dialect and are kept as they are
+var typedHeaderRegex = regexp.MustCompile(`^(.*?)\[.*\]\s*$`)
+
+// Conversion is the translation of the type of a column, e.g. 'TIMESTAMP_LTZ(9)' to 'timestamp_tz()'
+type Conversion struct {
+	Column string
+	From   string // The sql type of the column in the source dialect
+	To     string // The annotated type of the column in the target dialect
+	Lossy  string // Why the target type does not keep every value or property of the source type. Empty when the conversion is lossless
+}
+
+func (c Conversion) String() string {
+	return fmt.Sprintf("column '%s' %s -> %s", c.Column, c.From, c.To)
+}
+
+// rule translates the sql types of the source dialect matched by its pattern to an annotated type of the target dialect.
+// The values of the column are passed for types whose translation depends on them, e.g. the scale of a postgres 'numeric'
+type rule struct {
+	pattern   *regexp.Regexp
+	translate func(match []string, values []string) (typ string, lossy string)
+}
+
+// static returns a rule translation to a fixed type
+func static(typ string, lossy string) func([]string, []string) (string, string) {
+	return func([]string, []string) (string, string) {
+		return typ, lossy
+	}
+}
+
+// rules are the translations between the dialects, keyed by source and target dialect
+var rules = map[string]map[string][]rule{
+	"snowflake": {
+		"postgres": {
+			{pattern: regexp.MustCompile(`^NUMBER\((\d+),0\)$`), translate: func(match []string, _ []string) (string, string) {
+				precision, _ := strconv.Atoi(match[1])
+				switch {
+				case precision <= 9:
+					return "int()", ""
+				case precision <= 18:
+					return "bigint()", ""
+				default:
+					return "bigint()", fmt.Sprintf("NUMBER(%d,0) holds integers of up to %d digits, bigint of up to 18", precision, precision)
+				}
+			}},
+			{pattern: regexp.MustCompile(`^NUMBER\((\d+),(\d+)\)$`), translate: func(match []string, _ []string) (string, string) {
+				return fmt.Sprintf("numeric(%s,%s)", match[1], match[2]), ""
+			}},
+			{pattern: regexp.MustCompile(`^VARCHAR\(\d+\)$`), translate: static("text()", "")},
+			{pattern: regexp.MustCompile(`^BOOLEAN$`), translate: static("boolean()", "")},
+			{pattern: regexp.MustCompile(`^VARIANT$`), translate: static("jsonb()", "")},
+			{pattern: regexp.MustCompile(`^DATE$`), translate: static("date()", "")},
+			{pattern: regexp.MustCompile(`^TIME\(\d+\)$`), translate: static("time()", "")},
+			{pattern: regexp.MustCompile(`^(TIMESTAMP_NTZ|DATETIME)\(\d+\)$`), translate: static("timestamp()", "")},
+			{pattern: regexp.MustCompile(`^TIMESTAMP_TZ\(\d+\)$`), translate: static("timestamp_tz()", "")},
+			{pattern: regexp.MustCompile(`^TIMESTAMP_LTZ\(\d+\)$`), translate: static("timestamp_tz()", "the values are no longer in the time zone of the session")},
+		},
+	},
+	"postgres": {
+		"snowflake": {
+			{pattern: regexp.MustCompile(`^(smallint|int|bigint)$`), translate: static("number(38,0)", "")},
+			{pattern: regexp.MustCompile(`^numeric\((\d+),(\d+)\)$`), translate: func(match []string, _ []string) (string, string) {
+				precision, _ := strconv.Atoi(match[1])
+				if precision > 38 {
+					return fmt.Sprintf("number(38,%s)", match[2]), fmt.Sprintf("the precision %d is greater than the max precision 38 of NUMBER", precision)
+				}
+				return fmt.Sprintf("number(%s,%s)", match[1], match[2]), ""
+			}},
+			{pattern: regexp.MustCompile(`^numeric$`), translate: func(_ []string, values []string) (string, string) {
+				// A numeric without precision and scale holds any value, NUMBER gets the largest scale of the values
+				return fmt.Sprintf("number(38,%d)", maxScale(values)), "the scale of the numeric is no longer unconstrained"
+			}},
+			{pattern: regexp.MustCompile(`^text$`), translate: static("varchar()", "")},
+			{pattern: regexp.MustCompile(`^boolean$`), translate: static("boolean()", "")},
+			{pattern: regexp.MustCompile(`^jsonb$`), translate: static("variant()", "")},
+			{pattern: regexp.MustCompile(`^date$`), translate: static("date()", "")},
+			{pattern: regexp.MustCompile(`^time\(\d+\)$`), translate: static("time()", "")},
+			{pattern: regexp.MustCompile(`^time\(\d+\) with time zone$`), translate: static("time()", "snowflake has no time with time zone, the time zone is dropped")},
+			{pattern: regexp.MustCompile(`^timestamp\(\d+\)$`), translate: static("timestamp_ntz()", "")},
+			{pattern: regexp.MustCompile(`^timestamp\(\d+\) with time zone$`), translate: static("timestamp_tz()", "")},
+		},
+	},
+}
+
+// maxScale returns the largest number of decimals of the values, at most 37
+func maxScale(values []string) int {
+	scale := 0
+	for _, value := range values {
+		if dot := strings.Index(value, "."); dot >= 0 && len(value)-dot-1 > scale {
+			scale = len(value) - dot - 1
+		}
+	}
+	return min(scale, 37)
+}
+
+// Translate writes the data source with the annotated types of its header translated from the source to the target dialect, and returns the conversion of every typed column.
+// The table is the data source read by the source dialect: its values are written normalized to the default formats both dialects accept, e.g. a 'date(02/01/2006)' value as '2006-01-02'.
+// Cell expressions such as '=today-3d' are kept as they are written, and so are the constraints and the lossless portable types of the columns
+func Translate(w io.Writer, content []byte, table *formatter.Table, from string, to string, config formatter.CsvConfig) ([]Conversion, error) {
+	targets, ok := rules[from]
+	if !ok {
+		return nil, fmt.Errorf("dialect type '%s' not supported", from)
+	}
+	translations, ok := targets[to]
+	if !ok {
+		return nil, fmt.Errorf("translating from '%s' to '%s' is not supported", from, to)
+	}
+
+	header, records, err := readRecords(content, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(header) != len(table.Columns) {
+		return nil, fmt.Errorf("the header has %d columns, but %d were read", len(header), len(table.Columns))
+	}
+
+	var conversions []Conversion
+	translated := make([]string, len(header))
+	for i, h := range header {
+		match := typedHeaderRegex.FindStringSubmatch(h)
+		if match == nil {
+			translated[i] = h
+			continue
+		}
+		name := strings.TrimSpace(match[1])
+		typed, constraints := formatter.SplitConstraints(h)
+		typed = strings.TrimSpace(typed)
+		if strings.Contains(strings.ToLower(typed), "[expr(") {
+			// The sql expressions are written for the source dialect and are kept as they are
+			translated[i] = h
+			conversions = append(conversions, Conversion{Column: name, From: table.Columns[i].Type, To: typed[strings.Index(typed, "[")+1 : len(typed)-1], Lossy: "the sql expressions are not translated"})
+			continue
+		}
+
+		values := make([]string, 0, len(table.Rows))
+		for _, row := range table.Rows {
+			if i < len(row.Cells) {
+				values = append(values, row.Cells[i].Value)
+			}
+		}
+		conversion, err := convert(translations, name, table.Columns[i].Type, values)
+		if err != nil {
+			return nil, err
+		}
+		conversions = append(conversions, conversion)
+		if formatter.IsPortable(h) && conversion.Lossy == "" {
+			// A portable type is read by the target dialect as well
+			translated[i] = h
+			continue
+		}
+		translated[i] = fmt.Sprintf("%s[%s]", name, strings.Join(append([]string{conversion.To}, constraints...), "|"))
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = []rune(config.Separator)[0]
+	out := [][]string{translated}
+	for _, row := range table.Rows {
+		record := make([]string, len(row.Cells))
+		for i, cell := range row.Cells {
+			record[i] = cell.Value
+			// Expressions are evaluated by the target dialect when it reads the data source, and a value escaped with '==' must stay escaped
+			if raw, ok := records[row.Line]; ok && i < len(raw) && strings.HasPrefix(raw[i], "=") {
+				record[i] = raw[i]
+			}
+		}
+		out = append(out, record)
+	}
+	if err := cw.WriteAll(out); err != nil {
+		return nil, fmt.Errorf("error writing csv: %w", err)
+	}
+	return conversions, nil
+}
+
+// convert translates the sql type of a column with the first matching rule
+func convert(translations []rule, column string, typ string, values []string) (Conversion, error) {
+	for _, r := range translations {
+		if match := r.pattern.FindStringSubmatch(typ); match != nil {
+			to, lossy := r.translate(match, values)
+			return Conversion{Column: column, From: typ, To: to, Lossy: lossy}, nil
+		}
+	}
+	return Conversion{}, fmt.Errorf("type '%s' of column '%s' cannot be translated", typ, column)
+}
+
+// readRecords reads the header and the records of the data source as they are written, keyed by the line of the record
+func readRecords(content []byte, config formatter.CsvConfig) ([]string, map[int][]string, error) {
+	cr := csv.NewReader(bytes.NewReader(content))
+	cr.Comma = []rune(config.Separator)[0]
+	cr.Comment = []rune(config.Comment)[0]
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = config.TrimLeadingSpace
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading the header: %w", err)
+	}
+	records := map[int][]string{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading csv: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		records[line] = record
+	}
+	return header, records, nil
+}
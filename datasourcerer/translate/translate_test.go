@@ -0,0 +1,108 @@
+package translate_test
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/postgres"
+	"github.com/tsanton/dbt-unit-test-fusionizer/formatter/snowflake"
+	"github.com/tsanton/dbt-unit-test-fusionizer/translate"
+)
+
+var logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+func read(t *testing.T, dialect string, content string) formatter.IDataSourceFormatter {
+	config := &formatter.Config{Dialect: dialect, Filetype: formatter.ParserInputTypeCsv, CSV: formatter.NewDefaultCsvConfig()}
+	var f formatter.IDataSourceFormatter
+	if dialect == "snowflake" {
+		f = snowflake.Constructor()(logger, config)
+	} else {
+		f = postgres.Constructor()(logger, config)
+	}
+	assert.Nil(t, f.Read(strings.NewReader(content)))
+	return f
+}
+
+func Test_Translate(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		content  string
+		expected string
+		lossy    map[string]string
+	}{
+		{
+			name: "snowflake to postgres",
+			from: "snowflake",
+			to:   "postgres",
+			content: strings.TrimSpace(`
+"Id[number(38,0)|not_null]","Qty[number(9,0)]","Amount[number(10,2)]",Name[varchar(10)],"Active[boolean(Y,N)]",Due[date(02/01/2006)],Seen[timestamp_ltz()],Created[timestamp_ntz()],Note,Due[expr(DATE)],Doc[variant()]
+1,2,9.5,John,Y,31/01/2024,2024-01-31 08:30:00,=today-1d,==today,CURRENT_DATE - 1,"{""a"":1}"
+`),
+			expected: strings.TrimSpace(`
+Id[bigint()|not_null],Qty[int()],"Amount[numeric(10,2)]",Name[text()],Active[boolean()],Due[date()],Seen[timestamp_tz()],Created[timestamp()],Note,Due[expr(DATE)],Doc[jsonb()]
+1,2,9.5,John,true,2024-01-31,2024-01-31 08:30:00,=today-1d,==today,CURRENT_DATE - 1,"{""a"":1}"
+`),
+			lossy: map[string]string{
+				"Id":   "NUMBER(38,0) holds integers of up to 38 digits, bigint of up to 18",
+				"Seen": "the values are no longer in the time zone of the session",
+				"Due":  "the sql expressions are not translated",
+			},
+		},
+		{
+			name: "postgres to snowflake",
+			from: "postgres",
+			to:   "snowflake",
+			content: strings.TrimSpace(`
+Id[int()],Price[numeric()],Doc[jsonb()],At[timestamp_tz()],"Amount[decimal(10,2)]",Payload[json]
+1,2.125,"{""a"":1}",2024-01-31 08:30:00,1.5,{}
+`),
+			expected: strings.TrimSpace(`
+"Id[number(38,0)]","Price[number(38,3)]",Doc[variant()],At[timestamp_tz()],"Amount[decimal(10,2)]",Payload[json]
+1,2.125,"{""a"":1}",2024-01-31 08:30:00,1.5,{}
+`),
+			lossy: map[string]string{
+				"Price": "the scale of the numeric is no longer unconstrained",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			source := read(t, tt.from, tt.content)
+
+			var buf bytes.Buffer
+			conversions, err := translate.Translate(&buf, []byte(tt.content), source.Table(), tt.from, tt.to, formatter.NewDefaultCsvConfig())
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, strings.TrimSpace(buf.String()))
+
+			lossy := map[string]string{}
+			for _, conversion := range conversions {
+				if conversion.Lossy != "" {
+					lossy[conversion.Column] = conversion.Lossy
+				}
+			}
+			assert.Equal(t, tt.lossy, lossy)
+
+			// The translated data source is read by the target dialect
+			read(t, tt.to, buf.String())
+		})
+	}
+}
+
+func Test_Translate_Unsupported(t *testing.T) {
+	t.Parallel()
+	content := "\"Id[number(38,0)]\"\n1\n"
+	source := read(t, "snowflake", content)
+
+	var buf bytes.Buffer
+	_, err := translate.Translate(&buf, []byte(content), source.Table(), "snowflake", "snowflake", formatter.NewDefaultCsvConfig())
+	assert.EqualError(t, err, "translating from 'snowflake' to 'snowflake' is not supported")
+}
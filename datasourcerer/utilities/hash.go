@@ -0,0 +1,12 @@
+package utilities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Sha256 returns the hex encoded SHA-256 digest of the content
+func Sha256(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/tsanton/dbt-unit-test-fusionizer/generator"
+	"github.com/tsanton/dbt-unit-test-fusionizer/relationship"
+	"github.com/tsanton/dbt-unit-test-fusionizer/report"
+	"github.com/tsanton/dbt-unit-test-fusionizer/templatecrawler"
+)
+
+// runValidate checks the relationships of the config between the mocks of every test, e.g. that each 'customer_id' of the orders mock
+// is an 'id' of the customers mock of the same test. It takes the flags of the test generation and returns the process exit code
+func runValidate(args []string) int {
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return 2
+	}
+	if err := run.configureDefaults(logger); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	if err := run.parseConfigFile(logger); err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	relationships, err := relationship.ParseAll(run.config.Relationships)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	if len(relationships) == 0 {
+		logger.Error("no relationships to validate, declare them in 'relationships' of the config, e.g. 'orders.customer_id -> customers.id'")
+		return 1
+	}
+
+	c := make(chan templatecrawler.DataSourceReference)
+	adapter, err := templatecrawler.NewAdapter(run.config.Mocks)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+	crawler := templatecrawler.NewTestTemplateCrawler(logger, run.crawlers, run.templateDir).WithCases(run.cases).WithAdapter(adapter)
+	dataSources, parserIssues, err := parseDataSources(crawler, c)
+	if err != nil {
+		logger.Error(err.Error())
+		return 1
+	}
+
+	templates := crawler.GetTestTemplates()
+	rep := report.New()
+	rep.Add(crawler.Issues()...)
+	rep.Add(parserIssues...)
+	rep.Templates = len(*templates)
+	rep.DataSources = len(*dataSources)
+
+	g := generator.NewTestGenerator(logger, run.generators, run.unitTestDir)
+	violations, checked := g.Validate(templates, dataSources, relationships)
+	rep.Add(violations...)
+	if len(violations) > 0 {
+		fmt.Printf("%d relationship violation(s) in %d checked mock(s)\n", len(violations), checked)
+		return finish(rep, g, 1)
+	}
+	fmt.Printf("all %d relationship(s) hold in %d checked mock(s)\n", len(relationships), checked)
+	return finish(rep, g, 0)
+}